@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	stdos "os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/hack-pad/hackpadfs/os"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+type args struct {
+	WorldPath      string       `arg:"--path"`
+	WorldName      string       `arg:"--name"`
+	Players        []string     `arg:"--player,separate" help:"only report the player with the given UUID"`
+	IncludeRecipes bool         `arg:"--include-recipes" help:"include the advancements which unlock recipes"`
+	ReportCmd      *ReportCmd   `arg:"subcommand:report" help:"display each player's completed and incomplete advancements"`
+	TimelineCmd    *TimelineCmd `arg:"subcommand:timeline" help:"display every criterion each player has met, oldest first"`
+}
+
+type ReportCmd struct {
+	Incomplete bool `arg:"--incomplete" help:"only display incomplete advancements"`
+}
+
+type TimelineCmd struct{}
+
+func (args) Version() string {
+	return "mcutils v0.0.0"
+}
+
+func main() {
+	var args args
+	p := arg.MustParse(&args)
+	execadvancements(args, p)
+}
+
+func execadvancements(args args, p *arg.Parser) {
+	args.WorldPath = strings.Trim(args.WorldPath, string(filepath.Separator))
+
+	if len(args.WorldName) == 0 && len(args.WorldPath) == 0 {
+		p.Fail("must provide either --path or --name")
+	}
+
+	var nameDefined, pathDefined bool
+	if len(args.WorldName) > 0 {
+		nameDefined = true
+	}
+
+	if len(args.WorldPath) > 0 {
+		pathDefined = true
+	}
+
+	if nameDefined && pathDefined {
+		p.Fail("provide either both --path or --name not both")
+	}
+
+	if p.Subcommand() == nil {
+		p.Fail("missing subcommand")
+	}
+
+	if nameDefined {
+		if err := runCmd(args.WorldName, mc.OpenWorldByName, args); err != nil {
+			exit(err.Error())
+		}
+		return
+	}
+
+	if err := runCmd(args.WorldPath, mc.OpenWorld, args); err != nil {
+		exit(err.Error())
+	}
+}
+
+func runCmd(worldRef string, worldResolver mc.WorldResolver, args args) error {
+	fsys, err := resolveFS(string(filepath.Separator))
+	if err != nil {
+		return err
+	}
+
+	world, err := worldResolver(fsys, worldRef)
+
+	if err != nil {
+		if errors.Is(err, stdos.ErrNotExist) {
+			return fmt.Errorf("could not find world data for '%s'", worldRef)
+		}
+		return err
+	}
+
+	defer world.Close()
+
+	players, err := resolvePlayers(world, args.Players)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args.ReportCmd != nil:
+		reportCmd(players, args.ReportCmd, args.IncludeRecipes)
+	case args.TimelineCmd != nil:
+		timelineCmd(players, args.IncludeRecipes)
+	}
+
+	return nil
+}
+
+func resolvePlayers(world *mc.World, uuids []string) ([]mc.PlayerAdvancements, error) {
+	if len(uuids) == 0 {
+		return world.Advancements()
+	}
+
+	players := make([]mc.PlayerAdvancements, 0, len(uuids))
+	for _, uuid := range uuids {
+		p, err := world.PlayerAdvancements(uuid)
+		if err != nil {
+			return nil, err
+		}
+		players = append(players, p)
+	}
+
+	return players, nil
+}
+
+func reportCmd(players []mc.PlayerAdvancements, cmd *ReportCmd, includeRecipes bool) {
+	for _, p := range players {
+		var done, total int
+		fmt.Printf("[%s]\n", p.UUID)
+		for _, a := range p.Advancements {
+			if a.IsRecipe() && !includeRecipes {
+				continue
+			}
+
+			total++
+			if a.Done {
+				done++
+				if !cmd.Incomplete {
+					fmt.Printf("done %s %s\n", a.ID, a.CompletedAt().Format(mc.AdvancementTimeLayout))
+				}
+				continue
+			}
+
+			missing := a.Missing()
+			if missing == nil {
+				fmt.Printf("incomplete %s %d criteria met\n", a.ID, len(a.Criteria))
+				continue
+			}
+
+			fmt.Printf("incomplete %s %d of %d criteria met\n", a.ID, len(a.Criteria), len(a.Criteria)+len(missing))
+			for _, c := range missing {
+				fmt.Printf("  missing %s\n", c)
+			}
+		}
+		fmt.Printf("completed %d of %d\n", done, total)
+	}
+}
+
+func timelineCmd(players []mc.PlayerAdvancements, includeRecipes bool) {
+	for _, p := range players {
+		fmt.Printf("[%s]\n", p.UUID)
+		for _, c := range p.Timeline(includeRecipes) {
+			fmt.Printf("%s %s %s\n", c.Time.Format(mc.AdvancementTimeLayout), c.Advancement, c.Criterion)
+		}
+	}
+}
+
+func resolveFS(base string) (*os.FS, error) {
+	fs := os.NewFS()
+
+	baseDirectory, err := fs.FromOSPath(base) // Convert to an FS path
+	if err != nil {
+		return nil, err
+	}
+
+	baseDirFS, err := fs.Sub(baseDirectory) // Run all file system operations rooted at the current working directory
+	if err != nil {
+		return nil, err
+	}
+
+	ofs, ok := baseDirFS.(*os.FS)
+	if !ok {
+		return nil, errors.New("sub FS not an OS instance FS")
+	}
+
+	return ofs, nil
+}
+
+func exit(format string, a ...any) {
+	fmt.Fprintf(stdos.Stderr, format+"\n", a...)
+	stdos.Exit(1)
+}
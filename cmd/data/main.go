@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdos "os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/hack-pad/hackpadfs/os"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+type args struct {
+	WorldPath     string         `arg:"--path"`
+	WorldName     string         `arg:"--name"`
+	ViewCmd       *ViewCmd       `arg:"subcommand:view" help:"display any data file, such as raids or command_storage_minecraft, as JSON"`
+	RaidsCmd      *RaidsCmd      `arg:"subcommand:raids" help:"display the raids in progress as JSON"`
+	ScoreboardCmd *ScoreboardCmd `arg:"subcommand:scoreboard" help:"display the scoreboard objectives, scores and teams as JSON"`
+	MapsCmd       *MapsCmd       `arg:"subcommand:maps" help:"display the data of each filled map as JSON"`
+}
+
+type ViewCmd struct {
+	File      string `arg:"positional,required" help:"name of the data file, e.g. raids or map_0"`
+	Dimension string `arg:"--dimension" help:"dimension the data file is kept within, defaults to the overworld"`
+}
+
+type RaidsCmd struct {
+	Dimension string `arg:"--dimension" help:"dimension to display the raids of, defaults to the overworld"`
+}
+
+type ScoreboardCmd struct{}
+
+type MapsCmd struct {
+	IDs []int `arg:"--id,separate" help:"only display the map with the given ID"`
+}
+
+func (args) Version() string {
+	return "mcutils v0.0.0"
+}
+
+func main() {
+	var args args
+	p := arg.MustParse(&args)
+	execdata(args, p)
+}
+
+func execdata(args args, p *arg.Parser) {
+	args.WorldPath = strings.Trim(args.WorldPath, string(filepath.Separator))
+
+	if len(args.WorldName) == 0 && len(args.WorldPath) == 0 {
+		p.Fail("must provide either --path or --name")
+	}
+
+	var nameDefined, pathDefined bool
+	if len(args.WorldName) > 0 {
+		nameDefined = true
+	}
+
+	if len(args.WorldPath) > 0 {
+		pathDefined = true
+	}
+
+	if nameDefined && pathDefined {
+		p.Fail("provide either both --path or --name not both")
+	}
+
+	if p.Subcommand() == nil {
+		p.Fail("missing subcommand")
+	}
+
+	if nameDefined {
+		if err := runCmd(args.WorldName, mc.OpenWorldByName, p.Subcommand()); err != nil {
+			exit(err.Error())
+		}
+		return
+	}
+
+	if err := runCmd(args.WorldPath, mc.OpenWorld, p.Subcommand()); err != nil {
+		exit(err.Error())
+	}
+}
+
+func runCmd(worldRef string, worldResolver mc.WorldResolver, subCmd any) error {
+	fsys, err := resolveFS(string(filepath.Separator))
+	if err != nil {
+		return err
+	}
+
+	world, err := worldResolver(fsys, worldRef)
+
+	if err != nil {
+		if errors.Is(err, stdos.ErrNotExist) {
+			return fmt.Errorf("could not find world data for '%s'", worldRef)
+		}
+		return err
+	}
+
+	defer world.Close()
+
+	switch cmd := subCmd.(type) {
+	case *ViewCmd:
+		root, err := world.DataFile(cmd.File, cmd.Dimension)
+		if err != nil {
+			return err
+		}
+		return printJSON(root.Interface())
+	case *RaidsCmd:
+		raids, err := world.Raids(cmd.Dimension)
+		if err != nil {
+			return err
+		}
+		return printJSON(raids)
+	case *ScoreboardCmd:
+		sb, err := world.Scoreboard()
+		if err != nil {
+			return err
+		}
+		return printJSON(sb)
+	case *MapsCmd:
+		return mapsCmd(world, cmd)
+	}
+
+	return nil
+}
+
+func mapsCmd(world *mc.World, cmd *MapsCmd) error {
+	if len(cmd.IDs) == 0 {
+		maps, err := world.Maps()
+		if err != nil {
+			return err
+		}
+		return printJSON(maps)
+	}
+
+	maps := make([]mc.MapData, 0, len(cmd.IDs))
+	for _, id := range cmd.IDs {
+		m, err := world.Map(id)
+		if err != nil {
+			return err
+		}
+		maps = append(maps, m)
+	}
+
+	return printJSON(maps)
+}
+
+func printJSON(v any) error {
+	vJSON, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(vJSON))
+
+	return nil
+}
+
+func resolveFS(base string) (*os.FS, error) {
+	fs := os.NewFS()
+
+	baseDirectory, err := fs.FromOSPath(base) // Convert to an FS path
+	if err != nil {
+		return nil, err
+	}
+
+	baseDirFS, err := fs.Sub(baseDirectory) // Run all file system operations rooted at the current working directory
+	if err != nil {
+		return nil, err
+	}
+
+	ofs, ok := baseDirFS.(*os.FS)
+	if !ok {
+		return nil, errors.New("sub FS not an OS instance FS")
+	}
+
+	return ofs, nil
+}
+
+func exit(format string, a ...any) {
+	fmt.Fprintf(stdos.Stderr, format+"\n", a...)
+	stdos.Exit(1)
+}
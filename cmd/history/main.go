@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	stdos "os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexflint/go-arg"
+	"github.com/tauraamui/mcscan/internal/cli"
+	"github.com/tauraamui/mcscan/internal/storage"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+type args struct {
+	DB        string   `arg:"--db,required" help:"directory of the database scans were recorded in with scan --db"`
+	WorldPath string   `arg:"--path" help:"only list scans of the world at the given path"`
+	WorldName string   `arg:"--name" help:"only list scans of the saved world with the given name"`
+	Dimension string   `arg:"--dimension" help:"only list the given dimension, e.g. minecraft:the_nether or nether"`
+	Since     string   `arg:"--since" help:"only list scans made at or after the given date or RFC3339 time"`
+	Until     string   `arg:"--until" help:"only list scans made before the given date or RFC3339 time"`
+	Blocks    []string `arg:"--block,separate" help:"also list the count of the given block ID, e.g. minecraft:diamond_ore"`
+	Top       int      `arg:"--top" help:"list the given number of most common blocks of each scan instead"`
+	Dump      bool     `arg:"--dump" help:"print every entry within the database, decoded, instead"`
+	JSON      bool     `arg:"--json" help:"print entries as JSON, used with --dump"`
+}
+
+func (args) Version() string {
+	return "mcutils v0.0.0"
+}
+
+func main() {
+	var args args
+	p := arg.MustParse(&args)
+	exechistory(args, p)
+}
+
+func exechistory(args args, p *arg.Parser) {
+	args.WorldPath = strings.Trim(args.WorldPath, string(filepath.Separator))
+
+	if len(args.WorldName) > 0 && len(args.WorldPath) > 0 {
+		p.Fail("provide either both --path or --name not both")
+	}
+
+	// scans are recorded by the world's path, so the world needn't still
+	// exist
+	var world string
+	switch {
+	case len(args.WorldPath) > 0:
+		world = cli.ScanKey(args.WorldPath)
+	case len(args.WorldName) > 0:
+		path, err := mc.SavePath(args.WorldName)
+		if err != nil {
+			exit(err.Error())
+		}
+		world = cli.ScanKey(path)
+	}
+
+	if args.JSON && !args.Dump {
+		p.Fail("--json requires --dump")
+	}
+
+	q := storage.ScanQuery{World: world}
+	if len(args.Dimension) > 0 {
+		q.Dimension = mc.ParseDimensionID(args.Dimension)
+	}
+
+	var err error
+	if q.From, err = parseTime(args.Since); err != nil {
+		p.Fail(fmt.Sprintf("invalid --since: %v", err))
+	}
+
+	if q.Until, err = parseTime(args.Until); err != nil {
+		p.Fail(fmt.Sprintf("invalid --until: %v", err))
+	}
+
+	if err := runCmd(q, args); err != nil {
+		exit(err.Error())
+	}
+}
+
+// parseTime parses either a date, as local time, or an RFC3339 time, the
+// zero time is returned for an empty string.
+func parseTime(s string) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func runCmd(q storage.ScanQuery, args args) error {
+	if _, err := stdos.Stat(args.DB); err != nil {
+		return fmt.Errorf("unable to open database %s: %w", args.DB, err)
+	}
+
+	db, err := storage.NewDB(args.DB, storage.ReadOnly())
+	if err != nil {
+		return fmt.Errorf("unable to open database %s: %w", args.DB, err)
+	}
+	defer db.Close()
+
+	if args.Dump {
+		format := storage.DumpText
+		if args.JSON {
+			format = storage.DumpJSON
+		}
+		return db.DumpTo(stdos.Stdout, format)
+	}
+
+	scans, err := db.Scans(q)
+	if err != nil {
+		return err
+	}
+
+	if len(scans) == 0 {
+		fmt.Println("no scans recorded")
+		return nil
+	}
+
+	if args.Top > 0 {
+		return listTopBlocks(db, scans, args.Top)
+	}
+
+	w := tabwriter.NewWriter(stdos.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "WORLD\tTIME\tDIMENSION\tBLOCKS")
+	for _, id := range args.Blocks {
+		fmt.Fprintf(w, "\t%s", strings.ToUpper(strings.TrimPrefix(id, "minecraft:")))
+	}
+	fmt.Fprintln(w)
+
+	for _, s := range scans {
+		for _, dim := range s.Dimensions() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d", s.World, s.Time.Local().Format("2006-01-02 15:04:05"), dim, s.Total(dim))
+			for _, id := range args.Blocks {
+				fmt.Fprintf(w, "\t%d", s.Counts[dim][id])
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	return w.Flush()
+}
+
+func listTopBlocks(db storage.DB, scans []storage.Scan, n int) error {
+	for _, s := range scans {
+		for _, dim := range s.Dimensions() {
+			top, err := db.TopCounts(storage.ScanQuery{
+				World:     s.World,
+				Dimension: dim,
+				From:      s.Time,
+				Until:     s.Time.Add(time.Nanosecond),
+			}, n)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("[%s %s %s]\n", s.World, s.Time.Local().Format("2006-01-02 15:04:05"), dim)
+			for _, c := range top {
+				fmt.Printf("%s %d\n", c.Block, c.Count)
+			}
+		}
+	}
+
+	return nil
+}
+
+func exit(format string, a ...any) {
+	fmt.Fprintf(stdos.Stderr, format+"\n", a...)
+	stdos.Exit(1)
+}
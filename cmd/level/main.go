@@ -6,19 +6,26 @@ import (
 	"fmt"
 	stdos "os"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/alexflint/go-arg"
 	"github.com/hack-pad/hackpadfs/os"
+	"github.com/tauraamui/mcscan/internal/cli"
+	"github.com/tauraamui/mcscan/internal/nbtree"
 	mc "github.com/tauraamui/mcscan/pkg/minecraft"
 )
 
 type args struct {
-	WorldPath string   `arg:"--path"`
-	WorldName string   `arg:"--name"`
-	ViewCmd   *ViewCmd `arg:"subcommand:view" help:"display world level data as JSON"`
-	EditCmd   *EditCmd `arg:"subcommand:edit" help:"update level data fields to given values"`
+	WorldPath   string       `arg:"--path"`
+	WorldName   string       `arg:"--name"`
+	DryRun      bool         `arg:"--dry-run" help:"display what edits would change without writing anything"`
+	IgnoreLock  bool         `arg:"--ignore-lock" help:"allow writing to a world in use by the game or a server"`
+	NoBackup    bool         `arg:"--no-backup" help:"skip backing up files into .mcscan/backups before writing them"`
+	ViewCmd     *ViewCmd     `arg:"subcommand:view" help:"display world level data as JSON"`
+	EditCmd     *EditCmd     `arg:"subcommand:edit" help:"update level data fields to given values"`
+	GameRuleCmd *GameRuleCmd `arg:"subcommand:gamerule" help:"list, display or update the world's game rules"`
 }
 
 func (args) Version() string {
@@ -56,24 +63,25 @@ func execlevel(args args, p *arg.Parser) {
 	}
 
 	if nameDefined {
-		if err := runCmd(args.WorldName, mc.OpenWorldByName, p.Subcommand()); err != nil {
-			exit(err.Error())
+		if err := runCmd(args.WorldName, mc.OpenWorldByName, args, p.Subcommand()); err != nil {
+			exit(cli.ErrMessage(err))
 		}
 		return
 	}
 
-	if err := runCmd(args.WorldPath, mc.OpenWorld, p.Subcommand()); err != nil {
-		exit(err.Error())
+	if err := runCmd(args.WorldPath, mc.OpenWorld, args, p.Subcommand()); err != nil {
+		exit(cli.ErrMessage(err))
 	}
 }
 
-func runCmd(worldRef string, worldResolver mc.WorldResolver, subCmd any) error {
+func runCmd(worldRef string, worldResolver mc.WorldResolver, args args, subCmd any) error {
 	fsys, err := resolveFS(string(filepath.Separator))
 	if err != nil {
 		return err
 	}
 
-	world, err := worldResolver(fsys, worldRef)
+	flags := cli.WriteFlags{DryRun: args.DryRun, NoBackup: args.NoBackup, IgnoreLock: args.IgnoreLock}
+	world, err := worldResolver(fsys, worldRef, cli.WorldOptions(flags, writes(subCmd))...)
 
 	if err != nil {
 		if errors.Is(err, stdos.ErrNotExist) {
@@ -82,11 +90,25 @@ func runCmd(worldRef string, worldResolver mc.WorldResolver, subCmd any) error {
 		return err
 	}
 
+	defer world.Close()
+
+	if world.InUse() && !args.IgnoreLock {
+		fmt.Fprintln(stdos.Stderr, "warning: world is in use by the game or a server, opened read only")
+	}
+
 	switch cmd := subCmd.(type) {
 	case *ViewCmd:
 		return viewCmd(world)
 	case *EditCmd:
 		return editCmd(world, cmd)
+	case *GameRuleListCmd:
+		return gameRuleListCmd(world, cmd)
+	case *GameRuleGetCmd:
+		return gameRuleGetCmd(world, cmd)
+	case *GameRuleSetCmd:
+		return gameRuleSetCmd(world, cmd)
+	case *GameRuleCmd:
+		return errors.New("missing gamerule subcommand, one of list, get or set")
 	}
 
 	/*
@@ -104,72 +126,94 @@ func runCmd(worldRef string, worldResolver mc.WorldResolver, subCmd any) error {
 }
 
 type EditCmd struct {
-	Values map[string]string
+	Values map[string]string `arg:"--values,required" help:"tags to set as path=value, e.g. DayTime=18000 GameRules.keepInventory=true"`
+	Types  map[string]string `arg:"--type" help:"types to parse values as by path, e.g. GameRules.mod=string, existing tags keep their type otherwise"`
+	Force  bool              `arg:"--force" help:"allow adding tags and changing the type of existing tags"`
 }
 
 func editCmd(world *mc.World, cmd *EditCmd) error {
-	lvl, err := world.ReadLevel()
-	if err != nil {
-		return err
+	paths := make([]string, 0, len(cmd.Values))
+	for path := range cmd.Values {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
 
-	lvlJSON, err := json.Marshal(lvl)
-	if err != nil {
+	edits := make([]mc.LevelEdit, 0, len(paths))
+	for _, path := range paths {
+		edits = append(edits, mc.SetLevelValue(path, cmd.Values[path], cmd.Types[path], cmd.Force))
+	}
+
+	if err := world.EditLevel(edits...); err != nil {
+		switch {
+		case cmd.Force:
+		case errors.Is(err, mc.ErrTagTypeChange):
+			return fmt.Errorf("%w, use --force to allow it", err)
+		case errors.Is(err, nbtree.ErrNotFound):
+			return fmt.Errorf("%w, use --force to add it", err)
+		}
 		return err
 	}
 
-	var lvlData map[string]any
-	if err := json.Unmarshal(lvlJSON, &lvlData); err != nil {
+	return nil
+}
+
+type GameRuleCmd struct {
+	ListCmd *GameRuleListCmd `arg:"subcommand:list" help:"display every game rule's value alongside its default"`
+	GetCmd  *GameRuleGetCmd  `arg:"subcommand:get" help:"display a game rule's value"`
+	SetCmd  *GameRuleSetCmd  `arg:"subcommand:set" help:"update a game rule, keeping the previous level data as level.dat_old"`
+}
+
+type GameRuleListCmd struct {
+	Modified bool `arg:"--modified" help:"only display game rules which differ from their default"`
+}
+
+func gameRuleListCmd(world *mc.World, cmd *GameRuleListCmd) error {
+	rules, err := world.GameRules()
+	if err != nil {
 		return err
 	}
 
-	for editValKey, editValVal := range cmd.Values {
-		existingVal, ok := lvlData[editValKey]
-		if !ok {
-			return fmt.Errorf("property %s not found", editValKey)
+	w := tabwriter.NewWriter(stdos.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tVALUE\tDEFAULT\tMODIFIED")
+	for _, r := range rules {
+		if cmd.Modified && !r.Modified() {
+			continue
 		}
 
-		switch existingVal.(type) {
-		case string:
-			lvlData[editValKey] = editValVal
-		case int:
-			valAsInt, err := strconv.Atoi(editValVal)
-			if err != nil {
-				return err
-			}
-			lvlData[editValKey] = valAsInt
-		case float32:
-			valAsFloat, err := strconv.ParseFloat(editValVal, 32)
-			if err != nil {
-				return err
-			}
-			lvlData[editValKey] = valAsFloat
-		case float64:
-			valAsFloat, err := strconv.ParseFloat(editValVal, 64)
-			if err != nil {
-				return err
-			}
-			lvlData[editValKey] = valAsFloat
-		case bool:
-			valAsBool, err := strconv.ParseBool(editValVal)
-			if err != nil {
-				return err
-			}
-			lvlData[editValKey] = valAsBool
+		def := r.Default
+		if !r.Vanilla {
+			def = "-"
 		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", r.Name, r.Type, r.Value, def, r.Modified())
 	}
+	return w.Flush()
+}
+
+type GameRuleGetCmd struct {
+	Name string `arg:"positional,required" help:"name of the game rule, e.g. keepInventory"`
+}
 
-	lvlDataBytes, err := json.Marshal(lvlData)
+func gameRuleGetCmd(world *mc.World, cmd *GameRuleGetCmd) error {
+	r, err := world.GameRule(cmd.Name)
 	if err != nil {
 		return err
 	}
 
-	editedLvl := mc.Level{}
-	if err := json.Unmarshal(lvlDataBytes, &editedLvl); err != nil {
-		return err
-	}
+	fmt.Println(r.Value)
+	return nil
+}
+
+type GameRuleSetCmd struct {
+	Name  string `arg:"positional,required" help:"name of the game rule, e.g. keepInventory"`
+	Value string `arg:"positional,required" help:"true or false, or an integer, depending on the game rule"`
+	Force bool   `arg:"--force" help:"allow setting game rules which aren't vanilla, such as those added by mods"`
+}
 
-	if err := world.WriteLevel(&editedLvl); err != nil {
+func gameRuleSetCmd(world *mc.World, cmd *GameRuleSetCmd) error {
+	if err := world.EditLevel(mc.SetGameRule(cmd.Name, cmd.Value, cmd.Force)); err != nil {
+		if errors.Is(err, mc.ErrUnknownGameRule) {
+			return fmt.Errorf("%w, use --force to set it anyway", err)
+		}
 		return err
 	}
 
@@ -194,6 +238,15 @@ func viewCmd(world *mc.World) error {
 	return nil
 }
 
+// writes reports whether the given subcommand writes to the world.
+func writes(subCmd any) bool {
+	switch subCmd.(type) {
+	case *EditCmd, *GameRuleSetCmd:
+		return true
+	}
+	return false
+}
+
 func resolveFS(base string) (*os.FS, error) {
 	fs := os.NewFS()
 
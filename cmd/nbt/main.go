@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdos "os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/hack-pad/hackpadfs/os"
+	"github.com/tauraamui/mcscan/internal/cli"
+	"github.com/tauraamui/mcscan/internal/nbtree"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+type args struct {
+	WorldPath  string     `arg:"--path"`
+	WorldName  string     `arg:"--name"`
+	DryRun     bool       `arg:"--dry-run" help:"display what edits would change without writing anything"`
+	IgnoreLock bool       `arg:"--ignore-lock" help:"allow writing to a world in use by the game or a server"`
+	NoBackup   bool       `arg:"--no-backup" help:"skip backing up files into .mcscan/backups before writing them"`
+	Format     string     `arg:"--format" default:"snbt" help:"output format, either snbt or json"`
+	Compact    bool       `arg:"--compact" help:"print output on a single line"`
+	ViewCmd    *ViewCmd   `arg:"subcommand:view" help:"display a file, or chunk such as region/r.0.0.mca#3,7, within the world"`
+	GetCmd     *GetCmd    `arg:"subcommand:get" help:"display the tag at the given path, such as Data.GameRules.keepInventory"`
+	SetCmd     *SetCmd    `arg:"subcommand:set" help:"set the tag at the given path, keeping every other tag as it is"`
+	DeleteCmd  *DeleteCmd `arg:"subcommand:delete" help:"remove the tag at the given path"`
+}
+
+type ViewCmd struct {
+	File string `arg:"positional,required" help:"file relative to the world, e.g. level.dat or region/r.0.0.mca#3,7"`
+}
+
+type GetCmd struct {
+	File string `arg:"positional,required" help:"file relative to the world, e.g. level.dat or region/r.0.0.mca#3,7"`
+	Path string `arg:"positional,required" help:"path of the tag, e.g. Data.GameRules.keepInventory or Inventory[0].id"`
+}
+
+type SetCmd struct {
+	File  string `arg:"positional,required" help:"file relative to the world, e.g. level.dat or region/r.0.0.mca#3,7"`
+	Path  string `arg:"positional,required" help:"path of the tag, e.g. Data.GameRules.keepInventory or Inventory[0].id"`
+	Value string `arg:"positional,required" help:"value parsed as the existing tag's type, or as SNBT for a new tag"`
+	Type  string `arg:"--type" help:"parse the value as the given type, e.g. int or compound, changing the tag's type if needed"`
+}
+
+type DeleteCmd struct {
+	File string `arg:"positional,required" help:"file relative to the world, e.g. level.dat or region/r.0.0.mca#3,7"`
+	Path string `arg:"positional,required" help:"path of the tag, e.g. Data.WanderingTraderId or Inventory[0]"`
+}
+
+func (args) Version() string {
+	return "mcutils v0.0.0"
+}
+
+func main() {
+	var args args
+	p := arg.MustParse(&args)
+	execnbt(args, p)
+}
+
+func execnbt(args args, p *arg.Parser) {
+	args.WorldPath = strings.Trim(args.WorldPath, string(filepath.Separator))
+
+	if len(args.WorldName) == 0 && len(args.WorldPath) == 0 {
+		p.Fail("must provide either --path or --name")
+	}
+
+	var nameDefined, pathDefined bool
+	if len(args.WorldName) > 0 {
+		nameDefined = true
+	}
+
+	if len(args.WorldPath) > 0 {
+		pathDefined = true
+	}
+
+	if nameDefined && pathDefined {
+		p.Fail("provide either both --path or --name not both")
+	}
+
+	if args.Format != "snbt" && args.Format != "json" {
+		p.Fail("--format must be either snbt or json")
+	}
+
+	if p.Subcommand() == nil {
+		p.Fail("missing subcommand")
+	}
+
+	if nameDefined {
+		if err := runCmd(args.WorldName, mc.OpenWorldByName, args, p.Subcommand()); err != nil {
+			exit(cli.ErrMessage(err))
+		}
+		return
+	}
+
+	if err := runCmd(args.WorldPath, mc.OpenWorld, args, p.Subcommand()); err != nil {
+		exit(cli.ErrMessage(err))
+	}
+}
+
+func runCmd(worldRef string, worldResolver mc.WorldResolver, args args, subCmd any) error {
+	fsys, err := resolveFS(string(filepath.Separator))
+	if err != nil {
+		return err
+	}
+
+	flags := cli.WriteFlags{DryRun: args.DryRun, NoBackup: args.NoBackup, IgnoreLock: args.IgnoreLock}
+	world, err := worldResolver(fsys, worldRef, cli.WorldOptions(flags, writes(subCmd))...)
+
+	if err != nil {
+		if errors.Is(err, stdos.ErrNotExist) {
+			return fmt.Errorf("could not find world data for '%s'", worldRef)
+		}
+		return err
+	}
+
+	defer world.Close()
+
+	if world.InUse() && !args.IgnoreLock {
+		fmt.Fprintln(stdos.Stderr, "warning: world is in use by the game or a server, opened read only")
+	}
+
+	switch cmd := subCmd.(type) {
+	case *ViewCmd:
+		f, err := world.ReadNBT(cmd.File)
+		if err != nil {
+			return err
+		}
+		return printNode(f.Root.Node, args)
+	case *GetCmd:
+		return getCmd(world, cmd, args)
+	case *SetCmd:
+		return setCmd(world, cmd)
+	case *DeleteCmd:
+		return deleteCmd(world, cmd)
+	}
+
+	return nil
+}
+
+func getCmd(world *mc.World, cmd *GetCmd, args args) error {
+	f, err := world.ReadNBT(cmd.File)
+	if err != nil {
+		return err
+	}
+
+	path, err := nbtree.ParsePath(cmd.Path)
+	if err != nil {
+		return err
+	}
+
+	n, err := nbtree.Get(f.Root.Node, path)
+	if err != nil {
+		return err
+	}
+
+	return printNode(n, args)
+}
+
+func setCmd(world *mc.World, cmd *SetCmd) error {
+	f, err := world.ReadNBT(cmd.File)
+	if err != nil {
+		return err
+	}
+
+	path, err := nbtree.ParsePath(cmd.Path)
+	if err != nil {
+		return err
+	}
+
+	var n nbtree.Node
+	switch existing, err := nbtree.Get(f.Root.Node, path); {
+	case len(cmd.Type) > 0:
+		typ, err := nbtree.ParseType(cmd.Type)
+		if err != nil {
+			return err
+		}
+
+		if n, err = nbtree.ParseValue(typ, cmd.Value); err != nil {
+			return err
+		}
+	case err == nil:
+		if n, err = nbtree.ParseValue(existing.Type, cmd.Value); err != nil {
+			return err
+		}
+	case errors.Is(err, nbtree.ErrNotFound):
+		if n, err = nbtree.ParseSNBT(cmd.Value); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	if err := nbtree.Set(f.Root.Node, path, n); err != nil {
+		return err
+	}
+
+	return world.WriteNBT(f)
+}
+
+func deleteCmd(world *mc.World, cmd *DeleteCmd) error {
+	f, err := world.ReadNBT(cmd.File)
+	if err != nil {
+		return err
+	}
+
+	path, err := nbtree.ParsePath(cmd.Path)
+	if err != nil {
+		return err
+	}
+
+	if err := nbtree.Delete(f.Root.Node, path); err != nil {
+		return err
+	}
+
+	return world.WriteNBT(f)
+}
+
+func printNode(n nbtree.Node, args args) error {
+	if args.Format == "json" {
+		var (
+			nJSON []byte
+			err   error
+		)
+		if args.Compact {
+			nJSON, err = json.Marshal(n.Interface())
+		} else {
+			nJSON, err = json.MarshalIndent(n.Interface(), "", "  ")
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(nJSON))
+		return nil
+	}
+
+	indent := "  "
+	if args.Compact {
+		indent = ""
+	}
+	fmt.Println(nbtree.FormatSNBT(n, indent))
+
+	return nil
+}
+
+// writes reports whether the given subcommand writes to the world.
+func writes(subCmd any) bool {
+	switch subCmd.(type) {
+	case *SetCmd, *DeleteCmd:
+		return true
+	}
+	return false
+}
+
+func resolveFS(base string) (*os.FS, error) {
+	fs := os.NewFS()
+
+	baseDirectory, err := fs.FromOSPath(base) // Convert to an FS path
+	if err != nil {
+		return nil, err
+	}
+
+	baseDirFS, err := fs.Sub(baseDirectory) // Run all file system operations rooted at the current working directory
+	if err != nil {
+		return nil, err
+	}
+
+	ofs, ok := baseDirFS.(*os.FS)
+	if !ok {
+		return nil, errors.New("sub FS not an OS instance FS")
+	}
+
+	return ofs, nil
+}
+
+func exit(format string, a ...any) {
+	fmt.Fprintf(stdos.Stderr, format+"\n", a...)
+	stdos.Exit(1)
+}
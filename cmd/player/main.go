@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdos "os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/hack-pad/hackpadfs/os"
+	"github.com/tauraamui/mcscan/internal/cli"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+type args struct {
+	WorldPath  string   `arg:"--path"`
+	WorldName  string   `arg:"--name"`
+	DryRun     bool     `arg:"--dry-run" help:"display what edits would change without writing anything"`
+	IgnoreLock bool     `arg:"--ignore-lock" help:"allow writing to a world in use by the game or a server"`
+	NoBackup   bool     `arg:"--no-backup" help:"skip backing up files into .mcscan/backups before writing them"`
+	ListCmd    *ListCmd `arg:"subcommand:list" help:"display a summary of every player as JSON"`
+	ViewCmd    *ViewCmd `arg:"subcommand:view" help:"display a player's data as JSON"`
+	EditCmd    *EditCmd `arg:"subcommand:edit" help:"update a player's data, keeping the previous data as <uuid>.dat_old"`
+}
+
+func (args) Version() string {
+	return "mcutils v0.0.0"
+}
+
+func main() {
+	var args args
+	p := arg.MustParse(&args)
+	execplayer(args, p)
+}
+
+func execplayer(args args, p *arg.Parser) {
+	args.WorldPath = strings.Trim(args.WorldPath, string(filepath.Separator))
+
+	if len(args.WorldName) == 0 && len(args.WorldPath) == 0 {
+		p.Fail("must provide either --path or --name")
+	}
+
+	var nameDefined, pathDefined bool
+	if len(args.WorldName) > 0 {
+		nameDefined = true
+	}
+
+	if len(args.WorldPath) > 0 {
+		pathDefined = true
+	}
+
+	if nameDefined && pathDefined {
+		p.Fail("provide either both --path or --name not both")
+	}
+
+	if p.Subcommand() == nil {
+		p.Fail("missing subcommand")
+	}
+
+	if nameDefined {
+		if err := runCmd(args.WorldName, mc.OpenWorldByName, args, p.Subcommand()); err != nil {
+			exit(cli.ErrMessage(err))
+		}
+		return
+	}
+
+	if err := runCmd(args.WorldPath, mc.OpenWorld, args, p.Subcommand()); err != nil {
+		exit(cli.ErrMessage(err))
+	}
+}
+
+func runCmd(worldRef string, worldResolver mc.WorldResolver, args args, subCmd any) error {
+	fsys, err := resolveFS(string(filepath.Separator))
+	if err != nil {
+		return err
+	}
+
+	flags := cli.WriteFlags{DryRun: args.DryRun, NoBackup: args.NoBackup, IgnoreLock: args.IgnoreLock}
+	world, err := worldResolver(fsys, worldRef, cli.WorldOptions(flags, writes(subCmd))...)
+
+	if err != nil {
+		if errors.Is(err, stdos.ErrNotExist) {
+			return fmt.Errorf("could not find world data for '%s'", worldRef)
+		}
+		return err
+	}
+
+	defer world.Close()
+
+	if world.InUse() && !args.IgnoreLock {
+		fmt.Fprintln(stdos.Stderr, "warning: world is in use by the game or a server, opened read only")
+	}
+
+	switch cmd := subCmd.(type) {
+	case *ListCmd:
+		return listCmd(world)
+	case *ViewCmd:
+		return viewCmd(world, cmd)
+	case *EditCmd:
+		return editCmd(world, cmd)
+	}
+
+	return nil
+}
+
+type ListCmd struct{}
+
+type playerSummary struct {
+	UUID      string
+	Dimension string
+	Pos       mc.EntityPos
+	GameMode  mc.GameMode
+	Health    float32
+	XPLevel   int
+}
+
+func listCmd(world *mc.World) error {
+	players, err := world.Players()
+	if err != nil {
+		return err
+	}
+
+	summaries := make([]playerSummary, len(players))
+	for i, p := range players {
+		summaries[i] = playerSummary{
+			UUID:      p.UUID,
+			Dimension: p.Dimension,
+			Pos:       p.Pos,
+			GameMode:  p.GameMode,
+			Health:    p.Health,
+			XPLevel:   p.XPLevel,
+		}
+	}
+
+	summariesJSON, err := json.Marshal(summaries)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(summariesJSON))
+
+	return nil
+}
+
+type ViewCmd struct {
+	UUID string `arg:"positional,required" help:"UUID of the player to view"`
+}
+
+func viewCmd(world *mc.World, cmd *ViewCmd) error {
+	player, err := world.Player(cmd.UUID)
+	if err != nil {
+		return err
+	}
+
+	playerJSON, err := json.Marshal(&player)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(playerJSON))
+
+	return nil
+}
+
+type EditCmd struct {
+	UUID           string   `arg:"positional,required" help:"UUID of the player to edit"`
+	Pos            string   `arg:"--pos" help:"teleport the player to x,y,z"`
+	Dimension      string   `arg:"--dimension" help:"move the player into the given dimension, e.g. overworld"`
+	Health         *float32 `arg:"--health" help:"set the player's health, 20 is full health"`
+	Food           *int     `arg:"--food" help:"set the player's food level, from 0 to 20"`
+	XPLevel        *int     `arg:"--xp-level" help:"set the player's experience level"`
+	GameMode       string   `arg:"--gamemode" help:"set the player's game mode, e.g. survival or creative"`
+	Give           []string `arg:"--give,separate" help:"put an item into an inventory slot as slot:id[:count], e.g. 0:minecraft:torch:64"`
+	Remove         []int    `arg:"--remove-slot,separate" help:"empty the given inventory slot"`
+	ClearInventory bool     `arg:"--clear-inventory" help:"empty the player's entire inventory before any items are given"`
+}
+
+func editCmd(world *mc.World, cmd *EditCmd) error {
+	edits, err := playerEdits(cmd)
+	if err != nil {
+		return err
+	}
+
+	return world.EditPlayer(cmd.UUID, edits...)
+}
+
+// playerEdits builds the edits requested by the given flags, in the order
+// they are to be applied.
+func playerEdits(cmd *EditCmd) ([]mc.PlayerEdit, error) {
+	// clearing happens first, so there would be nothing left to remove
+	if cmd.ClearInventory && len(cmd.Remove) > 0 {
+		return nil, errors.New("--remove-slot cannot be used with --clear-inventory")
+	}
+
+	var edits []mc.PlayerEdit
+
+	if len(cmd.Pos) > 0 || len(cmd.Dimension) > 0 {
+		if len(cmd.Pos) == 0 {
+			return nil, errors.New("--dimension requires --pos")
+		}
+
+		pos, err := parsePos(cmd.Pos)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --pos: %w", err)
+		}
+		edits = append(edits, mc.TeleportPlayer(pos, cmd.Dimension))
+	}
+
+	if cmd.Health != nil {
+		edits = append(edits, mc.SetPlayerHealth(*cmd.Health))
+	}
+
+	if cmd.Food != nil {
+		edits = append(edits, mc.SetPlayerFood(*cmd.Food))
+	}
+
+	if cmd.XPLevel != nil {
+		edits = append(edits, mc.SetPlayerXPLevel(*cmd.XPLevel))
+	}
+
+	if len(cmd.GameMode) > 0 {
+		mode, err := mc.ParseGameMode(cmd.GameMode)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, mc.SetPlayerGameMode(mode))
+	}
+
+	if cmd.ClearInventory {
+		edits = append(edits, mc.ClearPlayerInventory())
+	}
+
+	for _, slot := range cmd.Remove {
+		edits = append(edits, mc.RemovePlayerItem(slot))
+	}
+
+	for _, give := range cmd.Give {
+		item, err := parseGive(give)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --give %s: %w", give, err)
+		}
+		edits = append(edits, mc.GivePlayerItem(item))
+	}
+
+	if len(edits) == 0 {
+		return nil, errors.New("nothing to edit")
+	}
+
+	return edits, nil
+}
+
+func parsePos(s string) (mc.EntityPos, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return mc.EntityPos{}, fmt.Errorf("expected 3 comma separated values, got %d", len(parts))
+	}
+
+	coords := make([]float64, 3)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return mc.EntityPos{}, err
+		}
+		coords[i] = v
+	}
+
+	return mc.EntityPos{X: coords[0], Y: coords[1], Z: coords[2]}, nil
+}
+
+// parseGive parses an item as slot:id[:count], the ID may include its
+// namespace such as 0:minecraft:torch:64.
+func parseGive(s string) (mc.Item, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 {
+		return mc.Item{}, errors.New("expected slot:id[:count]")
+	}
+
+	slot, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return mc.Item{}, fmt.Errorf("invalid slot: %w", err)
+	}
+	parts = parts[1:]
+
+	item := mc.Item{Slot: slot, Count: 1}
+	if last := parts[len(parts)-1]; len(parts) > 1 {
+		if count, err := strconv.Atoi(last); err == nil {
+			item.Count = count
+			parts = parts[:len(parts)-1]
+		}
+	}
+	item.ID = strings.Join(parts, ":")
+
+	return item, nil
+}
+
+// writes reports whether the given subcommand writes to the world.
+func writes(subCmd any) bool {
+	switch subCmd.(type) {
+	case *EditCmd:
+		return true
+	}
+	return false
+}
+
+func resolveFS(base string) (*os.FS, error) {
+	fs := os.NewFS()
+
+	baseDirectory, err := fs.FromOSPath(base) // Convert to an FS path
+	if err != nil {
+		return nil, err
+	}
+
+	baseDirFS, err := fs.Sub(baseDirectory) // Run all file system operations rooted at the current working directory
+	if err != nil {
+		return nil, err
+	}
+
+	ofs, ok := baseDirFS.(*os.FS)
+	if !ok {
+		return nil, errors.New("sub FS not an OS instance FS")
+	}
+
+	return ofs, nil
+}
+
+func exit(format string, a ...any) {
+	fmt.Fprintf(stdos.Stderr, format+"\n", a...)
+	stdos.Exit(1)
+}
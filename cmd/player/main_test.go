@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestPlayerEditsRejectsRemovingSlotsFromClearedInventory(t *testing.T) {
+	_, err := playerEdits(&EditCmd{ClearInventory: true, Remove: []int{0}})
+	if err == nil {
+		t.Fatal("expected --clear-inventory with --remove-slot to be rejected")
+	}
+
+	edits, err := playerEdits(&EditCmd{ClearInventory: true, Give: []string{"0:minecraft:torch:64"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(edits) != 2 {
+		t.Errorf("expected 2 edits, got %d", len(edits))
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	stdos "os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+	"github.com/hack-pad/hackpadfs/os"
+	"github.com/tauraamui/mcscan/internal/cli"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+type args struct {
+	WorldPath  string `arg:"--path"`
+	WorldName  string `arg:"--name"`
+	DryRun     bool   `arg:"--dry-run" help:"display what restoring would change without writing anything"`
+	IgnoreLock bool   `arg:"--ignore-lock" help:"allow writing to a world in use by the game or a server"`
+	Backup     string `arg:"positional" help:"ID of the backup to restore, every backup is listed if not given"`
+}
+
+func (args) Version() string {
+	return "mcutils v0.0.0"
+}
+
+func main() {
+	var args args
+	p := arg.MustParse(&args)
+	execrestore(args, p)
+}
+
+func execrestore(args args, p *arg.Parser) {
+	args.WorldPath = strings.Trim(args.WorldPath, string(filepath.Separator))
+
+	if len(args.WorldName) == 0 && len(args.WorldPath) == 0 {
+		p.Fail("must provide either --path or --name")
+	}
+
+	var nameDefined, pathDefined bool
+	if len(args.WorldName) > 0 {
+		nameDefined = true
+	}
+
+	if len(args.WorldPath) > 0 {
+		pathDefined = true
+	}
+
+	if nameDefined && pathDefined {
+		p.Fail("provide either both --path or --name not both")
+	}
+
+	if nameDefined {
+		if err := runCmd(args.WorldName, mc.OpenWorldByName, args); err != nil {
+			exit(cli.ErrMessage(err))
+		}
+		return
+	}
+
+	if err := runCmd(args.WorldPath, mc.OpenWorld, args); err != nil {
+		exit(cli.ErrMessage(err))
+	}
+}
+
+func runCmd(worldRef string, worldResolver mc.WorldResolver, args args) error {
+	fsys, err := resolveFS(string(filepath.Separator))
+	if err != nil {
+		return err
+	}
+
+	opts := cli.WorldOptions(cli.WriteFlags{DryRun: args.DryRun, IgnoreLock: args.IgnoreLock}, len(args.Backup) > 0)
+	world, err := worldResolver(fsys, worldRef, opts...)
+
+	if err != nil {
+		if errors.Is(err, stdos.ErrNotExist) {
+			return fmt.Errorf("could not find world data for '%s'", worldRef)
+		}
+		return err
+	}
+
+	defer world.Close()
+
+	if world.InUse() && !args.IgnoreLock {
+		fmt.Fprintln(stdos.Stderr, "warning: world is in use by the game or a server, opened read only")
+	}
+
+	if len(args.Backup) == 0 {
+		return listBackups(world)
+	}
+
+	b, err := world.Restore(args.Backup)
+	if err != nil {
+		return err
+	}
+
+	if !args.DryRun {
+		fmt.Printf("restored %d files and removed %d from backup %s\n", len(b.Files), len(b.Created), b.ID)
+	}
+
+	return nil
+}
+
+func listBackups(world *mc.World) error {
+	backups, err := world.Backups()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdos.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tFILES")
+	for _, b := range backups {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", b.ID, b.Time.Local().Format("2006-01-02 15:04:05"), strings.Join(b.Files, ", "))
+	}
+	return w.Flush()
+}
+
+func resolveFS(base string) (*os.FS, error) {
+	fs := os.NewFS()
+
+	baseDirectory, err := fs.FromOSPath(base) // Convert to an FS path
+	if err != nil {
+		return nil, err
+	}
+
+	baseDirFS, err := fs.Sub(baseDirectory) // Run all file system operations rooted at the current working directory
+	if err != nil {
+		return nil, err
+	}
+
+	ofs, ok := baseDirFS.(*os.FS)
+	if !ok {
+		return nil, errors.New("sub FS not an OS instance FS")
+	}
+
+	return ofs, nil
+}
+
+func exit(format string, a ...any) {
+	fmt.Fprintf(stdos.Stderr, format+"\n", a...)
+	stdos.Exit(1)
+}
@@ -3,18 +3,64 @@ package main
 import (
 	"errors"
 	"fmt"
+	"math"
 	stdos "os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alexflint/go-arg"
 	"github.com/hack-pad/hackpadfs/os"
+	"github.com/tauraamui/mcscan/internal/cli"
+	"github.com/tauraamui/mcscan/internal/storage"
 	mc "github.com/tauraamui/mcscan/pkg/minecraft"
 )
 
 type args struct {
-	WorldPath string `arg:"--path"`
-	WorldName string `arg:"--name"`
+	WorldPath   string       `arg:"--path"`
+	WorldName   string       `arg:"--name"`
+	Dimensions  []string     `arg:"--dimension,separate" help:"only scan the given dimension, e.g. minecraft:the_nether or nether"`
+	Find        []string     `arg:"--find,separate" help:"list the location of each block with the given ID, e.g. minecraft:spawner"`
+	Strict      bool         `arg:"--strict" help:"abort the scan on the first corrupt chunk instead of skipping it"`
+	Workers     int          `arg:"--workers" help:"number of chunks to decode at once, defaults to the number of CPUs"`
+	MaxRegions  int          `arg:"--max-open-regions" help:"number of region files to have open at once, defaults to 8"`
+	Min         string       `arg:"--min" help:"corner of the area to scan as x,y,z, requires --max"`
+	Max         string       `arg:"--max" help:"opposite corner of the area to scan as x,y,z, requires --min"`
+	Around      string       `arg:"--around" help:"centre of the area to scan as x,z, requires --radius"`
+	Radius      int          `arg:"--radius" help:"radius in blocks of the area to scan around --around"`
+	MinY        *int         `arg:"--min-y" help:"lowest Y level to scan, used with --around"`
+	MaxY        *int         `arg:"--max-y" help:"highest Y level to scan, used with --around"`
+	DB          string       `arg:"--db" help:"record the block counts in the database within the given directory, to be listed by history, only decoding chunks saved since the last scan"`
+	Full        bool         `arg:"--full" help:"decode every chunk rather than only those saved since the last scan with --db"`
+	ItemsCmd    *ItemsCmd    `arg:"subcommand:items" help:"report the items held within containers such as chests, barrels and shulker boxes"`
+	EntitiesCmd *EntitiesCmd `arg:"subcommand:entities" help:"report the entities, such as mobs and dropped items, within the world"`
+	POICmd      *POICmd      `arg:"subcommand:poi" help:"report the points of interest, such as workstations, beds and nether portals, within the world"`
+	DiffCmd     *DiffCmd     `arg:"subcommand:diff" help:"report how each block's count changed between two scans recorded with --db, or since another copy of the world"`
+}
+
+type ItemsCmd struct {
+	Items []string `arg:"--item,separate" help:"only report containers holding the given item ID, e.g. minecraft:diamond"`
+	List  bool     `arg:"--list" help:"list the location and contents of each container"`
+}
+
+type EntitiesCmd struct {
+	Types []string `arg:"--type,separate" help:"only report entities of the given type, e.g. minecraft:item"`
+	List  bool     `arg:"--list" help:"list the location of each entity"`
+}
+
+type POICmd struct {
+	Types []string `arg:"--type,separate" help:"only report points of interest of the given type, e.g. minecraft:nether_portal"`
+	List  bool     `arg:"--list" help:"list the location of each point of interest"`
+}
+
+type DiffCmd struct {
+	Against  string   `arg:"--against" help:"compare with the world at the given path, such as a backup copy, rather than recorded scans"`
+	From     string   `arg:"--from" help:"date or time of the recorded scan to compare from, defaults to the scan before --to"`
+	To       string   `arg:"--to" help:"date or time of the recorded scan to compare to, defaults to the latest scan"`
+	PerChunk bool     `arg:"--per-chunk" help:"report the changes within each chunk"`
+	Blocks   []string `arg:"--block,separate" help:"only report the given block ID, e.g. minecraft:diamond_ore"`
 }
 
 func (args) Version() string {
@@ -47,14 +93,44 @@ func execscan(args args, p *arg.Parser) {
 		p.Fail("provide either both --path or --name not both")
 	}
 
+	if (len(args.Min) > 0) != (len(args.Max) > 0) {
+		p.Fail("--min and --max must be provided together")
+	}
+
+	if (len(args.Around) > 0) != (args.Radius > 0) {
+		p.Fail("--around and a positive --radius must be provided together")
+	}
+
+	if len(args.Min) > 0 && len(args.Around) > 0 {
+		p.Fail("provide either --min and --max or --around not both")
+	}
+
+	if len(args.DB) > 0 && (len(args.Find) > 0 || len(args.Min) > 0 || len(args.Around) > 0 || (p.Subcommand() != nil && args.DiffCmd == nil)) {
+		p.Fail("--db only records whole block counts, it can't be used with --find, an area or a subcommand other than diff")
+	}
+
+	if cmd := args.DiffCmd; cmd != nil {
+		if (len(cmd.Against) > 0) == (len(args.DB) > 0) {
+			p.Fail("diff requires either --against or --db not both")
+		}
+
+		if len(cmd.Against) > 0 && (len(cmd.From) > 0 || len(cmd.To) > 0) {
+			p.Fail("--from and --to compare recorded scans, they can't be used with --against")
+		}
+	}
+
+	if args.Full && len(args.DB) == 0 {
+		p.Fail("--full requires --db")
+	}
+
 	if nameDefined {
-		if err := runCmd(args.WorldName, mc.OpenWorldByName); err != nil {
+		if err := runCmd(args.WorldName, mc.OpenWorldByName, args); err != nil {
 			exit(err.Error())
 		}
 		return
 	}
 
-	if err := runCmd(args.WorldPath, mc.OpenWorld); err != nil {
+	if err := runCmd(args.WorldPath, mc.OpenWorld, args); err != nil {
 		exit(err.Error())
 	}
 }
@@ -80,7 +156,7 @@ func resolveFS(base string) (*os.FS, error) {
 	return ofs, nil
 }
 
-func runCmd(worldRef string, worldResolver mc.WorldResolver) error {
+func runCmd(worldRef string, worldResolver mc.WorldResolver, args args) error {
 	fsys, err := resolveFS(string(filepath.Separator))
 	if err != nil {
 		return err
@@ -95,18 +171,629 @@ func runCmd(worldRef string, worldResolver mc.WorldResolver) error {
 		return err
 	}
 
-	blocks, err := world.BlocksCount()
+	defer world.Close()
+
+	var corrupted []*mc.ChunkCorruptError
+	opts := []mc.ScanOption{
+		mc.InDimensions(args.Dimensions...),
+		mc.WithWorkers(args.Workers),
+		mc.WithMaxOpenRegions(args.MaxRegions),
+	}
+
+	area, err := resolveArea(args)
 	if err != nil {
-		exit(err.Error())
+		return err
+	}
+
+	if area != nil {
+		opts = append(opts, mc.WithinArea(*area))
+	}
+
+	if !args.Strict {
+		opts = append(opts, mc.SkipCorruptChunks(func(err *mc.ChunkCorruptError) {
+			corrupted = append(corrupted, err)
+		}))
+	}
+
+	switch {
+	case args.ItemsCmd != nil:
+		err = itemsCmd(world, args.ItemsCmd, opts)
+	case args.EntitiesCmd != nil:
+		err = entitiesCmd(world, args.EntitiesCmd, opts)
+	case args.POICmd != nil:
+		err = poiCmd(world, args.POICmd, opts)
+	case args.DiffCmd != nil:
+		err = diffCmd(fsys, world, args, opts)
+	case len(args.Find) > 0:
+		err = findBlocks(world, args, opts)
+	default:
+		err = countBlocks(world, args, opts)
+	}
+
+	if len(corrupted) > 0 {
+		fmt.Fprintf(stdos.Stderr, "skipped %d damaged chunks or regions:\n", len(corrupted))
+		for _, c := range corrupted {
+			fmt.Fprintf(stdos.Stderr, "  %s\n", c)
+		}
+	}
+
+	return err
+}
+
+func resolveArea(args args) (*mc.Area, error) {
+	if len(args.Min) > 0 {
+		min, err := parseCoords(args.Min, 3)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --min: %w", err)
+		}
+
+		max, err := parseCoords(args.Max, 3)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --max: %w", err)
+		}
+
+		area := mc.Box(mc.BlockPos{X: min[0], Y: min[1], Z: min[2]}, mc.BlockPos{X: max[0], Y: max[1], Z: max[2]})
+		return &area, nil
+	}
+
+	if len(args.Around) > 0 {
+		centre, err := parseCoords(args.Around, 2)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --around: %w", err)
+		}
+
+		area := mc.Around(centre[0], centre[1], args.Radius)
+		if args.MinY != nil || args.MaxY != nil {
+			minY, maxY := math.MinInt, math.MaxInt
+			if args.MinY != nil {
+				minY = *args.MinY
+			}
+			if args.MaxY != nil {
+				maxY = *args.MaxY
+			}
+			area = area.WithinY(minY, maxY)
+		}
+		return &area, nil
+	}
+
+	return nil, nil
+}
+
+// parseCoords parses exactly n comma separated integers, such as 10,64,-20.
+func parseCoords(s string, n int) ([]int, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d comma separated values, got %d", n, len(parts))
+	}
+
+	coords := make([]int, n)
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		coords[i] = v
+	}
+
+	return coords, nil
+}
+
+func countBlocks(world *mc.World, args args, opts []mc.ScanOption) error {
+	var db *storage.DB
+	key := cli.ScanKey(world.Path())
+	if len(args.DB) > 0 {
+		opened, err := storage.NewDB(args.DB)
+		if err != nil {
+			return fmt.Errorf("unable to open database %s: %w", args.DB, err)
+		}
+		defer opened.Close()
+		db = &opened
+
+		if args.Full {
+			if err := db.ClearBlocksCountCache(key); err != nil {
+				return fmt.Errorf("unable to clear cached counts: %w", err)
+			}
+		}
+		opts = append(opts, mc.WithBlocksCountCache(db.BlocksCountCache(key)))
+	}
+
+	scannedAt := time.Now()
+	var dimBlocks map[string]map[string]uint64
+	if db == nil {
+		var err error
+		if dimBlocks, err = world.BlocksCount(opts...); err != nil {
+			return err
+		}
+	} else {
+		// each chunk's counts are recorded too, for diff --per-chunk
+		chunks, err := world.ChunkBlocksCount(opts...)
+		if err != nil {
+			return err
+		}
+
+		dimBlocks = map[string]map[string]uint64{}
+		for dim, dimChunks := range chunks {
+			dimBlocks[dim] = map[string]uint64{}
+			for _, counts := range dimChunks {
+				for id, n := range counts {
+					dimBlocks[dim][id] += n
+				}
+			}
+		}
+
+		if err := db.PutScan(storage.Scan{World: key, Time: scannedAt, Counts: dimBlocks, Chunks: chunks}); err != nil {
+			return fmt.Errorf("unable to record scan: %w", err)
+		}
+	}
+
+	for _, dim := range world.Dimensions() {
+		blocks, ok := dimBlocks[dim.ID]
+		if !ok {
+			continue
+		}
+
+		var total uint64
+		fmt.Printf("[%s]\n", dim.ID)
+		for k, v := range blocks {
+			fmt.Printf("%s %d\n", k, v)
+			total += v
+		}
+		fmt.Printf("total %d\n", total)
+	}
+
+	return nil
+}
+
+func findBlocks(world *mc.World, args args, opts []mc.ScanOption) error {
+	dimBlocks, err := world.FindBlocks(args.Find, opts...)
+	if err != nil {
+		return err
 	}
 
-	for k, v := range blocks {
-		fmt.Printf("%s %d\n", k, v)
+	for _, dim := range world.Dimensions() {
+		blocks, ok := dimBlocks[dim.ID]
+		if !ok {
+			continue
+		}
+
+		fmt.Printf("[%s]\n", dim.ID)
+		for _, blk := range blocks {
+			fmt.Printf("%s %d %d %d chunk %d %d\n", blk.ID, blk.Pos.X, blk.Pos.Y, blk.Pos.Z, blk.Chunk.X, blk.Chunk.Z)
+		}
+		fmt.Printf("total %d\n", len(blocks))
 	}
 
 	return nil
 }
 
+func itemsCmd(world *mc.World, cmd *ItemsCmd, opts []mc.ScanOption) error {
+	dimContainers, err := world.Containers(opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, dim := range world.Dimensions() {
+		containers, ok := dimContainers[dim.ID]
+		if !ok {
+			continue
+		}
+
+		if len(cmd.Items) > 0 {
+			containers = filterHolding(containers, cmd.Items)
+		}
+
+		var total uint64
+		fmt.Printf("[%s]\n", dim.ID)
+		for k, v := range mc.CountItems(containers) {
+			fmt.Printf("%s %d\n", k, v)
+			total += v
+		}
+		fmt.Printf("total %d in %d containers\n", total, len(containers))
+
+		if !cmd.List && len(cmd.Items) == 0 {
+			continue
+		}
+
+		for _, c := range containers {
+			fmt.Printf("%s %d %d %d chunk %d %d\n", c.ID, c.Pos.X, c.Pos.Y, c.Pos.Z, c.Chunk.X, c.Chunk.Z)
+			for k, v := range c.ItemCounts() {
+				fmt.Printf("  %s %d\n", k, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+func filterHolding(containers []mc.Container, ids []string) []mc.Container {
+	filtered := []mc.Container{}
+	for _, c := range containers {
+		for _, id := range ids {
+			if c.Holds(id) {
+				filtered = append(filtered, c)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func entitiesCmd(world *mc.World, cmd *EntitiesCmd, opts []mc.ScanOption) error {
+	dimEntities, err := world.Entities(opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, dim := range world.Dimensions() {
+		entities, ok := dimEntities[dim.ID]
+		if !ok {
+			continue
+		}
+
+		if len(cmd.Types) > 0 {
+			entities = filterEntities(entities, cmd.Types)
+		}
+
+		var total uint64
+		fmt.Printf("[%s]\n", dim.ID)
+		for k, v := range mc.CountEntities(entities) {
+			fmt.Printf("%s %d\n", k, v)
+			total += v
+		}
+		fmt.Printf("total %d\n", total)
+
+		if !cmd.List {
+			continue
+		}
+
+		for _, e := range entities {
+			printEntity(e, "")
+		}
+	}
+
+	return nil
+}
+
+func printEntity(e mc.Entity, indent string) {
+	fmt.Printf("%s%s %s %.2f %.2f %.2f chunk %d %d", indent, e.ID, e.UUID, e.Pos.X, e.Pos.Y, e.Pos.Z, e.Chunk.X, e.Chunk.Z)
+	if len(e.CustomName) > 0 {
+		fmt.Printf(" name %s", e.CustomName)
+	}
+	if e.Item != nil {
+		fmt.Printf(" item %s %d", e.Item.ID, e.Item.Count)
+	}
+	fmt.Printf(" health %.1f\n", e.Health)
+
+	for _, p := range e.Passengers {
+		printEntity(p, indent+"  ")
+	}
+}
+
+func filterEntities(entities []mc.Entity, types []string) []mc.Entity {
+	filtered := []mc.Entity{}
+	for _, e := range entities {
+		for _, t := range types {
+			if e.ID == t {
+				filtered = append(filtered, e)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func poiCmd(world *mc.World, cmd *POICmd, opts []mc.ScanOption) error {
+	dimPOIs, err := world.POIs(opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, dim := range world.Dimensions() {
+		pois, ok := dimPOIs[dim.ID]
+		if !ok {
+			continue
+		}
+
+		if len(cmd.Types) > 0 {
+			pois = filterPOIs(pois, cmd.Types)
+		}
+
+		fmt.Printf("[%s]\n", dim.ID)
+		for k, v := range mc.CountPOIs(pois) {
+			fmt.Printf("%s %d\n", k, v)
+		}
+		fmt.Printf("total %d\n", len(pois))
+
+		if !cmd.List {
+			continue
+		}
+
+		for _, p := range pois {
+			fmt.Printf("%s %d %d %d chunk %d %d free %d\n", p.Type, p.Pos.X, p.Pos.Y, p.Pos.Z, p.Chunk.X, p.Chunk.Z, p.FreeTickets)
+		}
+	}
+
+	return nil
+}
+
+func filterPOIs(pois []mc.POI, types []string) []mc.POI {
+	filtered := []mc.POI{}
+	for _, p := range pois {
+		for _, t := range types {
+			if p.Type == t {
+				filtered = append(filtered, p)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func diffCmd(fsys *os.FS, world *mc.World, args args, opts []mc.ScanOption) error {
+	cmd := args.DiffCmd
+	if len(cmd.Against) == 0 {
+		return diffRecordedScans(world, args)
+	}
+
+	other, err := mc.OpenWorld(fsys, strings.Trim(cmd.Against, string(filepath.Separator)))
+	if err != nil {
+		if errors.Is(err, stdos.ErrNotExist) {
+			return fmt.Errorf("could not find world data for '%s'", cmd.Against)
+		}
+		return err
+	}
+	defer other.Close()
+
+	if !cmd.PerChunk {
+		from, err := other.BlocksCount(opts...)
+		if err != nil {
+			return err
+		}
+
+		to, err := world.BlocksCount(opts...)
+		if err != nil {
+			return err
+		}
+
+		printDiff(world, from, to, cmd.Blocks)
+		return nil
+	}
+
+	from, err := other.ChunkBlocksCount(opts...)
+	if err != nil {
+		return err
+	}
+
+	to, err := world.ChunkBlocksCount(opts...)
+	if err != nil {
+		return err
+	}
+
+	printChunkDiff(world, from, to, cmd.Blocks)
+	return nil
+}
+
+func printChunkDiff(world *mc.World, from, to map[string]map[mc.ChunkPos]map[string]uint64, blocks []string) {
+	for _, dim := range diffDimensions(world, from, to) {
+		chunks := map[mc.ChunkPos]struct{}{}
+		for pos := range from[dim] {
+			chunks[pos] = struct{}{}
+		}
+		for pos := range to[dim] {
+			chunks[pos] = struct{}{}
+		}
+
+		sorted := make([]mc.ChunkPos, 0, len(chunks))
+		for pos := range chunks {
+			sorted = append(sorted, pos)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].X != sorted[j].X {
+				return sorted[i].X < sorted[j].X
+			}
+			return sorted[i].Z < sorted[j].Z
+		})
+
+		var total int64
+		fmt.Printf("[%s]\n", dim)
+		for _, pos := range sorted {
+			deltas := filterDeltas(mc.DiffCounts(from[dim][pos], to[dim][pos]), blocks)
+			if len(deltas) == 0 {
+				continue
+			}
+
+			fmt.Printf("chunk %d %d\n", pos.X, pos.Z)
+			total += printDeltas(deltas, "  ")
+		}
+		fmt.Printf("total %+d\n", total)
+	}
+}
+
+// diffRecordedScans reports the changes between two scans of the world
+// recorded with --db.
+func diffRecordedScans(world *mc.World, args args) error {
+	cmd := args.DiffCmd
+	db, err := storage.NewDB(args.DB, storage.ReadOnly())
+	if err != nil {
+		return fmt.Errorf("unable to open database %s: %w", args.DB, err)
+	}
+	defer db.Close()
+
+	toBound, err := parseScanTime(cmd.To)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+
+	key := cli.ScanKey(world.Path())
+	to, ok, err := db.LatestScan(key, toBound)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no scan of %s recorded to compare to", key)
+	}
+
+	fromBound := to
+	if len(cmd.From) > 0 {
+		if fromBound, err = parseScanTime(cmd.From); err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+
+	from, ok, err := db.LatestScan(key, fromBound)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no scan of %s recorded before %s to compare from", key, fromBound.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	fmt.Printf("from %s to %s\n", from.Local().Format("2006-01-02 15:04:05"), to.Local().Format("2006-01-02 15:04:05"))
+
+	if cmd.PerChunk {
+		chunks := make([]map[string]map[mc.ChunkPos]map[string]uint64, 2)
+		for i, t := range []time.Time{from, to} {
+			scanned, ok, err := db.ScanChunks(key, t)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("the scan of %s at %s recorded no chunk counts, it was made before they were recorded", key, t.Local().Format("2006-01-02 15:04:05"))
+			}
+			chunks[i] = onlyDimensions(scanned, args.Dimensions)
+		}
+
+		printChunkDiff(world, chunks[0], chunks[1], cmd.Blocks)
+		return nil
+	}
+
+	counts := make([]map[string]map[string]uint64, 2)
+	for i, t := range []time.Time{from, to} {
+		scans, err := db.Scans(storage.ScanQuery{World: key, From: t, Until: t.Add(time.Nanosecond)})
+		if err != nil {
+			return err
+		}
+
+		counts[i] = map[string]map[string]uint64{}
+		if len(scans) > 0 {
+			counts[i] = scans[0].Counts
+		}
+		counts[i] = onlyDimensions(counts[i], args.Dimensions)
+	}
+
+	printDiff(world, counts[0], counts[1], cmd.Blocks)
+
+	return nil
+}
+
+// onlyDimensions returns the counts of only the given dimensions, or every
+// count if none are given.
+func onlyDimensions[T any](counts map[string]T, dims []string) map[string]T {
+	if len(dims) == 0 {
+		return counts
+	}
+
+	only := map[string]T{}
+	for _, dim := range dims {
+		id := mc.ParseDimensionID(dim)
+		if c, ok := counts[id]; ok {
+			only[id] = c
+		}
+	}
+	return only
+}
+
+// parseScanTime parses a date, a time as listed by history, or an RFC3339
+// time, returning the end of the period it names, so that the latest scan
+// made before it was made during or before that period. The zero time is
+// returned for an empty string.
+func parseScanTime(s string) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local); err == nil {
+		return t.Add(time.Second), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(time.Nanosecond), nil
+}
+
+// diffDimensions returns the ID of each dimension within either count, in
+// the order the world's dimensions are in, followed by any others sorted.
+func diffDimensions[T any](world *mc.World, from, to map[string]T) []string {
+	seen := map[string]bool{}
+	var dims []string
+	for _, dim := range world.Dimensions() {
+		_, inFrom := from[dim.ID]
+		_, inTo := to[dim.ID]
+		if inFrom || inTo {
+			dims = append(dims, dim.ID)
+		}
+		seen[dim.ID] = true
+	}
+
+	var others []string
+	for _, counts := range []map[string]T{from, to} {
+		for dim := range counts {
+			if !seen[dim] {
+				others = append(others, dim)
+				seen[dim] = true
+			}
+		}
+	}
+	sort.Strings(others)
+
+	return append(dims, others...)
+}
+
+func printDiff(world *mc.World, from, to map[string]map[string]uint64, blocks []string) {
+	for _, dim := range diffDimensions(world, from, to) {
+		fmt.Printf("[%s]\n", dim)
+		total := printDeltas(filterDeltas(mc.DiffCounts(from[dim], to[dim]), blocks), "")
+		fmt.Printf("total %+d\n", total)
+	}
+}
+
+// printDeltas prints each block's delta sorted by block ID, returning the
+// sum of the deltas.
+func printDeltas(deltas map[string]int64, indent string) int64 {
+	ids := make([]string, 0, len(deltas))
+	for id := range deltas {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var total int64
+	for _, id := range ids {
+		fmt.Printf("%s%s %+d\n", indent, id, deltas[id])
+		total += deltas[id]
+	}
+	return total
+}
+
+func filterDeltas(deltas map[string]int64, blocks []string) map[string]int64 {
+	if len(blocks) == 0 {
+		return deltas
+	}
+
+	filtered := map[string]int64{}
+	for _, id := range blocks {
+		if d, ok := deltas[id]; ok {
+			filtered[id] = d
+		}
+	}
+	return filtered
+}
+
 func exit(format string, a ...any) {
 	fmt.Fprintf(stdos.Stderr, format+"\n", a...)
 	stdos.Exit(1)
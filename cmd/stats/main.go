@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdos "os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+	"github.com/hack-pad/hackpadfs/os"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+type args struct {
+	WorldPath  string      `arg:"--path"`
+	WorldName  string      `arg:"--name"`
+	Format     string      `arg:"--format" default:"table" help:"output format, one of table, json or csv"`
+	SummaryCmd *SummaryCmd `arg:"subcommand:summary" help:"display the total of each stat category per player"`
+	TopCmd     *TopCmd     `arg:"subcommand:top" help:"rank every player by the given stat"`
+}
+
+func (args) Version() string {
+	return "mcutils v0.0.0"
+}
+
+func main() {
+	var args args
+	p := arg.MustParse(&args)
+	execstats(args, p)
+}
+
+func execstats(args args, p *arg.Parser) {
+	args.WorldPath = strings.Trim(args.WorldPath, string(filepath.Separator))
+
+	if len(args.WorldName) == 0 && len(args.WorldPath) == 0 {
+		p.Fail("must provide either --path or --name")
+	}
+
+	var nameDefined, pathDefined bool
+	if len(args.WorldName) > 0 {
+		nameDefined = true
+	}
+
+	if len(args.WorldPath) > 0 {
+		pathDefined = true
+	}
+
+	if nameDefined && pathDefined {
+		p.Fail("provide either both --path or --name not both")
+	}
+
+	switch args.Format {
+	case "table", "json", "csv":
+	default:
+		p.Fail("--format must be one of table, json or csv")
+	}
+
+	if p.Subcommand() == nil {
+		p.Fail("missing subcommand")
+	}
+
+	if nameDefined {
+		if err := runCmd(args.WorldName, mc.OpenWorldByName, args.Format, p.Subcommand()); err != nil {
+			exit(err.Error())
+		}
+		return
+	}
+
+	if err := runCmd(args.WorldPath, mc.OpenWorld, args.Format, p.Subcommand()); err != nil {
+		exit(err.Error())
+	}
+}
+
+func runCmd(worldRef string, worldResolver mc.WorldResolver, format string, subCmd any) error {
+	fsys, err := resolveFS(string(filepath.Separator))
+	if err != nil {
+		return err
+	}
+
+	world, err := worldResolver(fsys, worldRef)
+
+	if err != nil {
+		if errors.Is(err, stdos.ErrNotExist) {
+			return fmt.Errorf("could not find world data for '%s'", worldRef)
+		}
+		return err
+	}
+
+	defer world.Close()
+
+	switch cmd := subCmd.(type) {
+	case *SummaryCmd:
+		return summaryCmd(world, cmd, format)
+	case *TopCmd:
+		return topCmd(world, cmd, format)
+	}
+
+	return nil
+}
+
+type SummaryCmd struct {
+	Players  []string `arg:"--player,separate" help:"only summarise the player with the given UUID"`
+	Category string   `arg:"--category" help:"list every stat within the given category, e.g. mined, instead of totals"`
+}
+
+type summaryRow struct {
+	UUID     string `json:"uuid"`
+	Category string `json:"category"`
+	Stat     string `json:"stat,omitempty"`
+	Value    int64  `json:"value"`
+}
+
+func summaryCmd(world *mc.World, cmd *SummaryCmd, format string) error {
+	var stats []mc.Stats
+	if len(cmd.Players) == 0 {
+		all, err := world.Stats()
+		if err != nil {
+			return err
+		}
+		stats = all
+	}
+
+	for _, uuid := range cmd.Players {
+		s, err := world.PlayerStats(uuid)
+		if err != nil {
+			return err
+		}
+		stats = append(stats, s)
+	}
+
+	rows := []summaryRow{}
+	for _, s := range stats {
+		if len(cmd.Category) == 0 {
+			for _, category := range s.Categories() {
+				rows = append(rows, summaryRow{UUID: s.UUID, Category: category, Value: s.Total(category)})
+			}
+			continue
+		}
+
+		category := cmd.Category
+		if !strings.Contains(category, ":") {
+			category = "minecraft:" + category
+		}
+
+		for stat, v := range s.Values[category] {
+			rows = append(rows, summaryRow{UUID: s.UUID, Category: category, Stat: stat, Value: v})
+		}
+	}
+
+	if len(cmd.Category) > 0 {
+		sortSummaryRows(rows)
+		return printRows(format, rows, []string{"uuid", "stat", "value"}, func(r summaryRow) []string {
+			return []string{r.UUID, r.Stat, strconv.FormatInt(r.Value, 10)}
+		})
+	}
+
+	return printRows(format, rows, []string{"uuid", "category", "total"}, func(r summaryRow) []string {
+		return []string{r.UUID, r.Category, strconv.FormatInt(r.Value, 10)}
+	})
+}
+
+// sortSummaryRows sorts each player's stats highest first.
+func sortSummaryRows(rows []summaryRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.UUID != b.UUID {
+			return a.UUID < b.UUID
+		}
+		if a.Value != b.Value {
+			return a.Value > b.Value
+		}
+		return a.Stat < b.Stat
+	})
+}
+
+type TopCmd struct {
+	Key   string `arg:"positional,required" help:"stat to rank by as category/stat, e.g. minecraft:mined/minecraft:diamond_ore"`
+	Limit int    `arg:"--limit" help:"only display the given number of players"`
+}
+
+func topCmd(world *mc.World, cmd *TopCmd, format string) error {
+	key, err := mc.ParseStatKey(cmd.Key)
+	if err != nil {
+		return err
+	}
+
+	stats, err := world.Stats()
+	if err != nil {
+		return err
+	}
+
+	entries := mc.Leaderboard(stats, key)
+	if cmd.Limit > 0 && len(entries) > cmd.Limit {
+		entries = entries[:cmd.Limit]
+	}
+
+	return printRows(format, entries, []string{"rank", "uuid", "value"}, func(e mc.LeaderboardEntry) []string {
+		return []string{strconv.Itoa(e.Rank), e.UUID, strconv.FormatInt(e.Value, 10)}
+	})
+}
+
+// printRows writes rows as JSON as they are, or as a table or CSV with
+// each row's columns given by toColumns.
+func printRows[T any](format string, rows []T, header []string, toColumns func(T) []string) error {
+	switch format {
+	case "json":
+		rowsJSON, err := json.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(rowsJSON))
+		return nil
+	case "csv":
+		w := csv.NewWriter(stdos.Stdout)
+		w.Write(header)
+		for _, r := range rows {
+			w.Write(toColumns(r))
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(stdos.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+	for _, r := range rows {
+		fmt.Fprintln(w, strings.Join(toColumns(r), "\t"))
+	}
+	return w.Flush()
+}
+
+func resolveFS(base string) (*os.FS, error) {
+	fs := os.NewFS()
+
+	baseDirectory, err := fs.FromOSPath(base) // Convert to an FS path
+	if err != nil {
+		return nil, err
+	}
+
+	baseDirFS, err := fs.Sub(baseDirectory) // Run all file system operations rooted at the current working directory
+	if err != nil {
+		return nil, err
+	}
+
+	ofs, ok := baseDirFS.(*os.FS)
+	if !ok {
+		return nil, errors.New("sub FS not an OS instance FS")
+	}
+
+	return ofs, nil
+}
+
+func exit(format string, a ...any) {
+	fmt.Fprintf(stdos.Stderr, format+"\n", a...)
+	stdos.Exit(1)
+}
@@ -7,6 +7,7 @@ require (
 	github.com/alexflint/go-arg v1.4.3
 	github.com/dgraph-io/badger/v3 v3.2103.5
 	github.com/hack-pad/hackpadfs v0.2.1
+	golang.org/x/sys v0.9.0
 )
 
 require (
@@ -26,7 +27,6 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	go.opencensus.io v0.24.0 // indirect
 	golang.org/x/net v0.11.0 // indirect
-	golang.org/x/sys v0.9.0 // indirect
 	google.golang.org/protobuf v1.30.0 // indirect
 )
 
@@ -0,0 +1,69 @@
+// Package cli holds what the commands share.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+// WriteFlags are the command line flags controlling how a world is written.
+type WriteFlags struct {
+	DryRun     bool
+	NoBackup   bool
+	IgnoreLock bool
+}
+
+// WorldOptions returns the options to open a world with, given whether the
+// command being run writes to it. Only writes hold session.lock, so that the
+// game can still start while a world is being viewed.
+func WorldOptions(flags WriteFlags, writes bool) []mc.WorldOption {
+	var opts []mc.WorldOption
+	if writes {
+		opts = append(opts, mc.WithSessionLock())
+	}
+
+	if flags.DryRun {
+		opts = append(opts, mc.WithDryRun(PrintDiff))
+	}
+
+	if flags.NoBackup {
+		opts = append(opts, mc.WithoutBackups())
+	}
+
+	if flags.IgnoreLock {
+		opts = append(opts, mc.WithIgnoreLock())
+	}
+
+	return opts
+}
+
+// PrintDiff displays the changes a dry run would have made to a file.
+func PrintDiff(diff mc.FileDiff) {
+	fmt.Println(diff.File)
+	if len(diff.Changes) == 0 {
+		fmt.Println("  no changes")
+	}
+
+	for _, c := range diff.Changes {
+		fmt.Println("  " + c.String())
+	}
+}
+
+// ErrMessage returns what to exit with for the given error, suggesting
+// how to get past it where there's a way.
+func ErrMessage(err error) string {
+	if errors.Is(err, mc.ErrWorldInUse) {
+		return err.Error() + ", use --ignore-lock to write anyway"
+	}
+	return err.Error()
+}
+
+// ScanKey returns what scans of the world at the given path, relative to the
+// root as the commands open worlds, are recorded under. That's the world's
+// cleaned absolute path, so worlds sharing a folder name are kept apart.
+func ScanKey(path string) string {
+	return filepath.Join(string(filepath.Separator), path)
+}
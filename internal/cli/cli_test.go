@@ -0,0 +1,22 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tauraamui/mcscan/internal/cli"
+)
+
+func TestScanKeyKeepsWorldsSharingANameApart(t *testing.T) {
+	saved, backup := cli.ScanKey("home/steve/saves/world"), cli.ScanKey("home/steve/backups/world")
+	if saved == backup {
+		t.Fatalf("expected worlds within different folders to have different keys, both got %s", saved)
+	}
+
+	// the same world is keyed the same however its path is given
+	for _, path := range []string{"/home/steve/saves/world", "home/steve/saves/./world/"} {
+		if key := cli.ScanKey(filepath.FromSlash(path)); key != saved {
+			t.Errorf("expected %s to be keyed %s, got %s", path, saved, key)
+		}
+	}
+}
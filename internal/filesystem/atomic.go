@@ -0,0 +1,89 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type syncer interface {
+	Sync() error
+}
+
+// WriteFileAtomic writes data to a temporary file within the same
+// directory as name, syncs it to disk, then renames it over name. Should
+// the write fail part way through, or the program crash, name is left
+// exactly as it was rather than holding part of data. An existing file
+// keeps its permissions, perm is only used for new files.
+func WriteFileAtomic(fsys FS, name string, data []byte, perm fs.FileMode) error {
+	dir, base := filepath.Split(name)
+
+	if fi, err := fsys.Stat(name); err == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	var (
+		tmp string
+		f   fs.File
+		err error
+	)
+	for i := 0; i < 10; i++ {
+		tmp = filepath.Join(dir, "."+base+".tmp"+strconv.FormatInt(time.Now().UnixNano(), 36))
+		f, err = fsys.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if !errors.Is(err, fs.ErrExist) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file for %s: %w", name, err)
+	}
+
+	if err := writeAndSync(f, data); err != nil {
+		f.Close()
+		fsys.Remove(tmp)
+		return fmt.Errorf("unable to write %s: %w", name, err)
+	}
+
+	if err := f.Close(); err != nil {
+		fsys.Remove(tmp)
+		return fmt.Errorf("unable to write %s: %w", name, err)
+	}
+
+	if err := fsys.Rename(tmp, name); err != nil {
+		fsys.Remove(tmp)
+		return fmt.Errorf("unable to replace %s: %w", name, err)
+	}
+
+	// the rename itself is only durable once the directory is synced, which
+	// isn't supported everywhere so is only done where it is
+	if d, err := fsys.Open(filepath.Clean(dir)); err == nil {
+		if s, ok := d.(syncer); ok {
+			s.Sync()
+		}
+		d.Close()
+	}
+
+	return nil
+}
+
+func writeAndSync(f fs.File, data []byte) error {
+	w, ok := f.(io.Writer)
+	if !ok {
+		return errors.New("file is not writable")
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	// filesystems held in memory have nothing to sync to
+	if s, ok := f.(syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
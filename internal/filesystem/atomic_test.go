@@ -0,0 +1,63 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tauraamui/mcscan/internal/filesystem"
+	"github.com/tauraamui/mcscan/internal/filesystem/filesystemtest"
+)
+
+func TestWriteFileAtomicKeepsOriginalOnFailure(t *testing.T) {
+	original := []byte("original level data")
+
+	for name, inject := range map[string]func(fsys *filesystemtest.FaultyFS){
+		"write":  func(fsys *filesystemtest.FaultyFS) { fsys.FailWriteAfter(".level.dat.tmp*", 4) },
+		"rename": func(fsys *filesystemtest.FaultyFS) { fsys.FailRename("level.dat") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			files := fstest.MapFS{"world/level.dat": &fstest.MapFile{Data: original}}
+			fsys := &filesystemtest.FaultyFS{FS: filesystemtest.MapFS{MapFS: files}}
+			inject(fsys)
+
+			err := filesystem.WriteFileAtomic(fsys, "world/level.dat", []byte("edited level data"), 0o644)
+			if !errors.Is(err, filesystemtest.ErrInjected) {
+				t.Fatalf("expected injected error, got %v", err)
+			}
+
+			if !bytes.Equal(files["world/level.dat"].Data, original) {
+				t.Errorf("expected original data to survive, got %q", files["world/level.dat"].Data)
+			}
+
+			if len(files) != 1 {
+				t.Errorf("expected temporary file to be removed, got %d files", len(files))
+			}
+		})
+	}
+
+	files := fstest.MapFS{"world/level.dat": &fstest.MapFile{Data: original}}
+	if err := filesystem.WriteFileAtomic(filesystemtest.MapFS{MapFS: files}, "world/level.dat", []byte("edited"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(files["world/level.dat"].Data) != "edited" || len(files) != 1 {
+		t.Errorf("expected level.dat to be replaced, got %q within %d files", files["world/level.dat"].Data, len(files))
+	}
+}
+
+func TestFaultyFSWriteFileLeavesPartialData(t *testing.T) {
+	files := fstest.MapFS{"world/level.dat": &fstest.MapFile{Data: []byte("original level data")}}
+	fsys := &filesystemtest.FaultyFS{FS: filesystemtest.MapFS{MapFS: files}}
+	fsys.FailWriteAfter("level.dat", 4)
+
+	// writing in place is what loses data, which is why it's avoided
+	if err := fsys.WriteFile("world/level.dat", []byte("edited level data"), 0o644); !errors.Is(err, filesystemtest.ErrInjected) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+
+	if string(files["world/level.dat"].Data) != "edit" {
+		t.Errorf("expected partial data, got %q", files["world/level.dat"].Data)
+	}
+}
@@ -0,0 +1,249 @@
+// Package filesystemtest implements filesystem.FS in memory for tests,
+// including one which fails writes part way through to stand in for the
+// program crashing or the disk filling up.
+package filesystemtest
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"github.com/tauraamui/mcscan/internal/filesystem"
+)
+
+// ErrInjected is returned by operations made to fail by FaultyFS.
+var ErrInjected = errors.New("injected failure")
+
+// MapFS is a writable fstest.MapFS.
+type MapFS struct {
+	fstest.MapFS
+}
+
+func (m MapFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.MapFS[name] = &fstest.MapFile{Data: data, Mode: perm}
+	return nil
+}
+
+// MkdirAll does nothing, as a MapFS has every directory holding a file.
+func (m MapFS) MkdirAll(path string, perm fs.FileMode) error {
+	return nil
+}
+
+func (m MapFS) OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return m.Open(name)
+	}
+
+	existing, exists := m.MapFS[name]
+	if exists && flag&os.O_EXCL != 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+
+	if !exists {
+		if flag&os.O_CREATE == 0 {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		existing = &fstest.MapFile{Mode: perm}
+		m.MapFS[name] = existing
+	}
+
+	if flag&os.O_TRUNC != 0 {
+		existing.Data = nil
+	}
+
+	return &mapFile{name: name, file: existing}, nil
+}
+
+func (m MapFS) Rename(oldname, newname string) error {
+	f, ok := m.MapFS[oldname]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrNotExist}
+	}
+	delete(m.MapFS, oldname)
+	m.MapFS[newname] = f
+	return nil
+}
+
+func (m MapFS) Remove(name string) error {
+	if _, ok := m.MapFS[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.MapFS, name)
+	return nil
+}
+
+// locks are the MapFS files currently locked, by any MapFS.
+var locks = struct {
+	sync.Mutex
+	held map[*fstest.MapFile]bool
+}{held: map[*fstest.MapFile]bool{}}
+
+// Lock locks the named file, creating it if need be, failing with
+// filesystem.ErrLocked if it's already locked.
+func (m MapFS) Lock(name string) (io.Closer, error) {
+	f, ok := m.MapFS[name]
+	if !ok {
+		f = &fstest.MapFile{Mode: 0o644}
+		m.MapFS[name] = f
+	}
+
+	locks.Lock()
+	defer locks.Unlock()
+	if locks.held[f] {
+		return nil, &fs.PathError{Op: "lock", Path: name, Err: filesystem.ErrLocked}
+	}
+	locks.held[f] = true
+
+	return lock{file: f}, nil
+}
+
+type lock struct {
+	file *fstest.MapFile
+}
+
+func (l lock) Close() error {
+	locks.Lock()
+	defer locks.Unlock()
+	delete(locks.held, l.file)
+	return nil
+}
+
+// mapFile is a MapFS file opened for writing, data written to it appears
+// within the MapFS straight away, as it would on disk.
+type mapFile struct {
+	name string
+	file *fstest.MapFile
+}
+
+func (f *mapFile) Write(p []byte) (int, error) {
+	f.file.Data = append(f.file.Data, p...)
+	return len(p), nil
+}
+
+func (f *mapFile) Stat() (fs.FileInfo, error) {
+	return fileInfo{name: path.Base(f.name), file: f.file}, nil
+}
+
+func (f *mapFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("file is open for writing")}
+}
+
+func (f *mapFile) Close() error {
+	return nil
+}
+
+type fileInfo struct {
+	name string
+	file *fstest.MapFile
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return int64(len(i.file.Data)) }
+func (i fileInfo) Mode() fs.FileMode  { return i.file.Mode }
+func (i fileInfo) ModTime() time.Time { return i.file.ModTime }
+func (i fileInfo) IsDir() bool        { return false }
+func (i fileInfo) Sys() any           { return nil }
+
+// FaultyFS wraps a filesystem.FS, failing the operations it's told to.
+type FaultyFS struct {
+	filesystem.FS
+
+	mu           sync.Mutex
+	writeFaults  []writeFault
+	renameFaults []string
+}
+
+type writeFault struct {
+	pattern string
+	after   int
+}
+
+// FailWriteAfter makes the next write to a file whose base name matches
+// the given path.Match pattern fail with ErrInjected, once the first n
+// bytes have been written.
+func (f *FaultyFS) FailWriteAfter(pattern string, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writeFaults = append(f.writeFaults, writeFault{pattern: pattern, after: n})
+}
+
+// FailRename makes the next rename onto a file whose base name matches the
+// given path.Match pattern fail with ErrInjected.
+func (f *FaultyFS) FailRename(pattern string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.renameFaults = append(f.renameFaults, pattern)
+}
+
+// takeWriteFault removes and returns the first write fault matching name.
+func (f *FaultyFS) takeWriteFault(name string) (writeFault, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, fault := range f.writeFaults {
+		if ok, _ := path.Match(fault.pattern, path.Base(name)); ok {
+			f.writeFaults = append(f.writeFaults[:i], f.writeFaults[i+1:]...)
+			return fault, true
+		}
+	}
+	return writeFault{}, false
+}
+
+func (f *FaultyFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if fault, ok := f.takeWriteFault(name); ok {
+		if fault.after < len(data) {
+			f.FS.WriteFile(name, data[:fault.after], perm)
+			return &fs.PathError{Op: "write", Path: name, Err: ErrInjected}
+		}
+	}
+	return f.FS.WriteFile(name, data, perm)
+}
+
+func (f *FaultyFS) OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error) {
+	file, err := f.FS.OpenFile(name, flag, perm)
+	if err != nil || flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return file, err
+	}
+
+	if fault, ok := f.takeWriteFault(name); ok {
+		return &faultyFile{File: file, name: name, remaining: fault.after}, nil
+	}
+	return file, nil
+}
+
+func (f *FaultyFS) Rename(oldname, newname string) error {
+	f.mu.Lock()
+	for i, pattern := range f.renameFaults {
+		if ok, _ := path.Match(pattern, path.Base(newname)); ok {
+			f.renameFaults = append(f.renameFaults[:i], f.renameFaults[i+1:]...)
+			f.mu.Unlock()
+			return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: ErrInjected}
+		}
+	}
+	f.mu.Unlock()
+
+	return f.FS.Rename(oldname, newname)
+}
+
+// faultyFile fails once more than remaining bytes are written to it.
+type faultyFile struct {
+	fs.File
+	name      string
+	remaining int
+}
+
+func (f *faultyFile) Write(p []byte) (int, error) {
+	w := f.File.(io.Writer)
+	if len(p) <= f.remaining {
+		f.remaining -= len(p)
+		return w.Write(p)
+	}
+
+	n, _ := w.Write(p[:f.remaining])
+	f.remaining = 0
+	return n, &fs.PathError{Op: "write", Path: f.name, Err: ErrInjected}
+}
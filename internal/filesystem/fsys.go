@@ -9,4 +9,10 @@ type FS interface {
 	fs.StatFS
 	fs.ReadDirFS
 	WriteFile(name string, data []byte, perm fs.FileMode) error
+	MkdirAll(path string, perm fs.FileMode) error
+	// OpenFile opens the named file with the given os.O_* flags, files
+	// opened for writing must implement io.Writer.
+	OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error)
+	Rename(oldname, newname string) error
+	Remove(name string) error
 }
@@ -0,0 +1,45 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrLocked is returned when a file is already locked by another process,
+// such as a world's session.lock while the game has the world open.
+var ErrLocked = errors.New("locked by another process")
+
+// LockFS is implemented by filesystems which lock files themselves.
+type LockFS interface {
+	Lock(name string) (io.Closer, error)
+}
+
+// osPathFS is implemented by filesystems backed by the OS's own, such as
+// hackpadfs's os.FS, whose files may be locked the same way any other
+// program would lock them.
+type osPathFS interface {
+	ToOSPath(fsPath string) (string, error)
+}
+
+// Lock takes an exclusive lock on the named file, creating it if need be,
+// the same way the game locks session.lock. The error wraps ErrLocked if
+// another process holds the lock. The lock is held until closed.
+// Filesystems unable to lock files, such as those held in memory, are
+// never locked.
+func Lock(fsys FS, name string) (io.Closer, error) {
+	switch l := fsys.(type) {
+	case LockFS:
+		return l.Lock(name)
+	case osPathFS:
+		path, err := l.ToOSPath(name)
+		if err != nil {
+			return nil, err
+		}
+		return lockFile(path)
+	}
+	return nopCloser{}, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
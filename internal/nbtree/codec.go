@@ -0,0 +1,414 @@
+package nbtree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// maxDepth is how deeply lists and compounds may be nested, the same
+// limit the game enforces.
+const maxDepth = 512
+
+// maxPrealloc is how many bytes an array may allocate before it's read.
+// Lengths come from the data itself, so a corrupt one could otherwise
+// allocate far more than the data holds.
+const maxPrealloc = 1 << 16
+
+// Read reads a single named root tag of uncompressed NBT data.
+func Read(r io.Reader) (Field, error) {
+	d := decoder{r: bufio.NewReader(r)}
+
+	typ, err := d.byte()
+	if err != nil {
+		return Field{}, fmt.Errorf("unable to read root tag type: %w", err)
+	}
+
+	if typ == TagEnd {
+		return Field{}, fmt.Errorf("root tag is empty")
+	}
+
+	name, err := d.string()
+	if err != nil {
+		return Field{}, fmt.Errorf("unable to read root tag name: %w", err)
+	}
+
+	n, err := d.node(typ, 0)
+	if err != nil {
+		return Field{}, err
+	}
+
+	return Field{Name: name, Node: n}, nil
+}
+
+type decoder struct {
+	r *bufio.Reader
+}
+
+func (d decoder) byte() (byte, error) {
+	return d.r.ReadByte()
+}
+
+func (d decoder) read(n int) ([]byte, error) {
+	if n <= maxPrealloc {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(d.r, buf)
+		return buf, err
+	}
+
+	// grows only as the data is read
+	buf, err := io.ReadAll(io.LimitReader(d.r, int64(n)))
+	if err == nil && len(buf) < n {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf, err
+}
+
+// prealloc returns how many of n elements of the given size may be
+// allocated before they're read.
+func prealloc(n, size int) int {
+	if n > maxPrealloc/size {
+		return maxPrealloc / size
+	}
+	return n
+}
+
+func (d decoder) uint16() (uint16, error) {
+	buf, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(buf), nil
+}
+
+func (d decoder) uint32() (uint32, error) {
+	buf, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(buf), nil
+}
+
+func (d decoder) uint64() (uint64, error) {
+	buf, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+func (d decoder) length() (int, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return 0, err
+	}
+
+	if int32(n) < 0 {
+		return 0, fmt.Errorf("negative length %d", int32(n))
+	}
+
+	return int(n), nil
+}
+
+func (d decoder) string() (string, error) {
+	n, err := d.uint16()
+	if err != nil {
+		return "", err
+	}
+
+	buf, err := d.read(int(n))
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func (d decoder) node(typ byte, depth int) (Node, error) {
+	if depth > maxDepth {
+		return Node{}, fmt.Errorf("tags nested deeper than %d", maxDepth)
+	}
+
+	switch typ {
+	case TagByte:
+		v, err := d.byte()
+		return Byte(int8(v)), err
+	case TagShort:
+		v, err := d.uint16()
+		return Short(int16(v)), err
+	case TagInt:
+		v, err := d.uint32()
+		return Int(int32(v)), err
+	case TagLong:
+		v, err := d.uint64()
+		return Long(int64(v)), err
+	case TagFloat:
+		v, err := d.uint32()
+		return Float(math.Float32frombits(v)), err
+	case TagDouble:
+		v, err := d.uint64()
+		return Double(math.Float64frombits(v)), err
+	case TagString:
+		v, err := d.string()
+		return String(v), err
+	case TagByteArray:
+		n, err := d.length()
+		if err != nil {
+			return Node{}, err
+		}
+
+		buf, err := d.read(n)
+		if err != nil {
+			return Node{}, err
+		}
+
+		v := make([]int8, n)
+		for i, b := range buf {
+			v[i] = int8(b)
+		}
+		return Node{Type: TagByteArray, Value: v}, nil
+	case TagIntArray:
+		n, err := d.length()
+		if err != nil {
+			return Node{}, err
+		}
+
+		v := make([]int32, 0, prealloc(n, 4))
+		for i := 0; i < n; i++ {
+			e, err := d.uint32()
+			if err != nil {
+				return Node{}, err
+			}
+			v = append(v, int32(e))
+		}
+		return Node{Type: TagIntArray, Value: v}, nil
+	case TagLongArray:
+		n, err := d.length()
+		if err != nil {
+			return Node{}, err
+		}
+
+		v := make([]int64, 0, prealloc(n, 8))
+		for i := 0; i < n; i++ {
+			e, err := d.uint64()
+			if err != nil {
+				return Node{}, err
+			}
+			v = append(v, int64(e))
+		}
+		return Node{Type: TagLongArray, Value: v}, nil
+	case TagList:
+		elemType, err := d.byte()
+		if err != nil {
+			return Node{}, err
+		}
+
+		n, err := d.length()
+		if err != nil {
+			return Node{}, err
+		}
+
+		l := &List{Type: elemType}
+		for i := 0; i < n; i++ {
+			item, err := d.node(elemType, depth+1)
+			if err != nil {
+				return Node{}, err
+			}
+			l.Items = append(l.Items, item)
+		}
+		return NewList(l), nil
+	case TagCompound:
+		c := &Compound{}
+		for {
+			fieldType, err := d.byte()
+			if err != nil {
+				return Node{}, err
+			}
+
+			if fieldType == TagEnd {
+				return NewCompound(c), nil
+			}
+
+			name, err := d.string()
+			if err != nil {
+				return Node{}, err
+			}
+
+			n, err := d.node(fieldType, depth+1)
+			if err != nil {
+				return Node{}, fmt.Errorf("%s: %w", name, err)
+			}
+			c.Fields = append(c.Fields, Field{Name: name, Node: n})
+		}
+	}
+
+	return Node{}, fmt.Errorf("unknown tag type %d", typ)
+}
+
+// Write writes the given root tag as uncompressed NBT data.
+func Write(w io.Writer, root Field) error {
+	e := encoder{w: bufio.NewWriter(w)}
+
+	e.byte(root.Type)
+	if err := e.string(root.Name); err != nil {
+		return err
+	}
+	if err := e.node(root.Node); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w *bufio.Writer
+}
+
+// the underlying writer's error is kept by bufio and returned on Flush,
+// so the individual writes don't need checking
+
+func (e encoder) byte(b byte) {
+	e.w.WriteByte(b)
+}
+
+func (e encoder) uint16(v uint16) {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], v)
+	e.w.Write(buf[:])
+}
+
+func (e encoder) uint32(v uint32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	e.w.Write(buf[:])
+}
+
+func (e encoder) uint64(v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	e.w.Write(buf[:])
+}
+
+func (e encoder) string(s string) error {
+	if len(s) > math.MaxUint16 {
+		return fmt.Errorf("string of %d bytes exceeds the maximum of %d", len(s), math.MaxUint16)
+	}
+
+	e.uint16(uint16(len(s)))
+	e.w.WriteString(s)
+	return nil
+}
+
+// length writes the length of an array or list, which is a signed int.
+func (e encoder) length(n int) error {
+	if int64(n) > math.MaxInt32 {
+		return fmt.Errorf("length %d exceeds the maximum of %d", n, math.MaxInt32)
+	}
+
+	e.uint32(uint32(n))
+	return nil
+}
+
+func (e encoder) node(n Node) error {
+	if !valueMatchesType(n) {
+		return fmt.Errorf("%T value for %s tag", n.Value, TypeName(n.Type))
+	}
+
+	switch v := n.Value.(type) {
+	case int8:
+		e.byte(byte(v))
+	case int16:
+		e.uint16(uint16(v))
+	case int32:
+		e.uint32(uint32(v))
+	case int64:
+		e.uint64(uint64(v))
+	case float32:
+		e.uint32(math.Float32bits(v))
+	case float64:
+		e.uint64(math.Float64bits(v))
+	case string:
+		return e.string(v)
+	case []int8:
+		if err := e.length(len(v)); err != nil {
+			return err
+		}
+		for _, b := range v {
+			e.byte(byte(b))
+		}
+	case []int32:
+		if err := e.length(len(v)); err != nil {
+			return err
+		}
+		for _, i := range v {
+			e.uint32(uint32(i))
+		}
+	case []int64:
+		if err := e.length(len(v)); err != nil {
+			return err
+		}
+		for _, i := range v {
+			e.uint64(uint64(i))
+		}
+	case *List:
+		e.byte(v.Type)
+		if err := e.length(len(v.Items)); err != nil {
+			return err
+		}
+		for _, item := range v.Items {
+			if item.Type != v.Type {
+				return fmt.Errorf("%s within list of %s", TypeName(item.Type), TypeName(v.Type))
+			}
+			if err := e.node(item); err != nil {
+				return err
+			}
+		}
+	case *Compound:
+		for _, f := range v.Fields {
+			e.byte(f.Type)
+			if err := e.string(f.Name); err != nil {
+				return err
+			}
+			if err := e.node(f.Node); err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+		}
+		e.byte(TagEnd)
+	default:
+		return fmt.Errorf("unsupported value %T", n.Value)
+	}
+
+	return nil
+}
+
+func valueMatchesType(n Node) bool {
+	switch n.Value.(type) {
+	case int8:
+		return n.Type == TagByte
+	case int16:
+		return n.Type == TagShort
+	case int32:
+		return n.Type == TagInt
+	case int64:
+		return n.Type == TagLong
+	case float32:
+		return n.Type == TagFloat
+	case float64:
+		return n.Type == TagDouble
+	case string:
+		return n.Type == TagString
+	case []int8:
+		return n.Type == TagByteArray
+	case []int32:
+		return n.Type == TagIntArray
+	case []int64:
+		return n.Type == TagLongArray
+	case *List:
+		return n.Type == TagList
+	case *Compound:
+		return n.Type == TagCompound
+	}
+	return false
+}
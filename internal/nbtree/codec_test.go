@@ -0,0 +1,118 @@
+package nbtree_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+func readGzipFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open %s: %v", path, err)
+	}
+	defer fd.Close()
+
+	r, err := gzip.NewReader(fd)
+	if err != nil {
+		t.Fatalf("unable to init gzip reader on %s: %v", path, err)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unable to read %s: %v", path, err)
+	}
+
+	return data
+}
+
+func TestReadWriteIsLossless(t *testing.T) {
+	for _, path := range []string{
+		"../../testdata/level.dat",
+		"../../testdata/playerdata/480c70ff-1bf6-44e3-8e42-f365f2d4fbef.dat",
+	} {
+		t.Run(path, func(t *testing.T) {
+			data := readGzipFile(t, path)
+
+			root, err := nbtree.Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := nbtree.Write(&buf, root); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(data, buf.Bytes()) {
+				t.Errorf("expected written data to match the %d bytes read, got %d bytes", len(data), buf.Len())
+			}
+		})
+	}
+}
+
+func TestWriteRejectsMismatchedTypes(t *testing.T) {
+	root := nbtree.Field{Node: nbtree.NewCompound(&nbtree.Compound{Fields: []nbtree.Field{
+		{Name: "Pos", Node: nbtree.NewList(&nbtree.List{
+			Type:  nbtree.TagDouble,
+			Items: []nbtree.Node{nbtree.Double(1), nbtree.Float(2)},
+		})},
+	}})}
+
+	if err := nbtree.Write(io.Discard, root); err == nil {
+		t.Error("expected an error writing a float within a list of doubles")
+	}
+}
+
+func TestWriteRejectsOverlongStrings(t *testing.T) {
+	long := strings.Repeat("a", math.MaxUint16+1)
+
+	for name, root := range map[string]nbtree.Field{
+		"value": {Node: nbtree.NewCompound(&nbtree.Compound{Fields: []nbtree.Field{
+			{Name: "CustomName", Node: nbtree.String(long)},
+		}})},
+		"field name": {Node: nbtree.NewCompound(&nbtree.Compound{Fields: []nbtree.Field{
+			{Name: long, Node: nbtree.Byte(1)},
+		}})},
+	} {
+		if err := nbtree.Write(io.Discard, root); err == nil {
+			t.Errorf("expected an error writing a %s longer than %d bytes", name, math.MaxUint16)
+		}
+	}
+
+	root := nbtree.Field{Node: nbtree.NewCompound(&nbtree.Compound{Fields: []nbtree.Field{
+		{Name: "CustomName", Node: nbtree.String(long[1:])},
+	}})}
+	if err := nbtree.Write(io.Discard, root); err != nil {
+		t.Errorf("unexpected error writing a string of %d bytes: %v", math.MaxUint16, err)
+	}
+}
+
+func TestReadOnlyAllocatesArraysAsTheyreRead(t *testing.T) {
+	for _, typ := range []byte{nbtree.TagByteArray, nbtree.TagIntArray, nbtree.TagLongArray} {
+		// an unnamed root compound holding an unnamed array claiming to hold
+		// math.MaxInt32 elements, but holding only a few bytes
+		data := []byte{nbtree.TagCompound, 0, 0, typ, 0, 0, 0x7f, 0xff, 0xff, 0xff, 1, 2, 3, 4}
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		if _, err := nbtree.Read(bytes.NewReader(data)); err == nil {
+			t.Errorf("expected an error reading a %s longer than the data", nbtree.TypeName(typ))
+		}
+
+		runtime.ReadMemStats(&after)
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("expected reading %s to allocate at most 1MiB, allocated %d bytes", nbtree.TypeName(typ), allocated)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package nbtree
+
+import (
+	"math"
+)
+
+// Change is a tag which differs between two trees. Old is nil when the tag
+// was added and New is nil when it was removed.
+type Change struct {
+	Path Path
+	Old  *Node
+	New  *Node
+}
+
+func (c Change) String() string {
+	switch {
+	case c.Old == nil:
+		return "+ " + c.Path.String() + ": " + FormatSNBT(*c.New, "")
+	case c.New == nil:
+		return "- " + c.Path.String() + ": " + FormatSNBT(*c.Old, "")
+	}
+	return "~ " + c.Path.String() + ": " + FormatSNBT(*c.Old, "") + " -> " + FormatSNBT(*c.New, "")
+}
+
+// Diff returns every tag which differs from a to b, in the order the tags
+// appear. Compounds and lists are compared tag by tag, whereas a tag of
+// any other type is reported as a whole.
+func Diff(a, b Node) []Change {
+	return diff(nil, a, b, nil)
+}
+
+func diff(path Path, a, b Node, changes []Change) []Change {
+	if a.Type != b.Type {
+		return append(changes, changed(path, &a, &b))
+	}
+
+	switch av := a.Value.(type) {
+	case *Compound:
+		bv := b.Value.(*Compound)
+		for _, f := range av.Fields {
+			fieldPath := append(path[:len(path):len(path)], PathElem{Name: f.Name})
+			other, ok := bv.Get(f.Name)
+			if !ok {
+				changes = append(changes, changed(fieldPath, &f.Node, nil))
+				continue
+			}
+			changes = diff(fieldPath, f.Node, other, changes)
+		}
+
+		for _, f := range bv.Fields {
+			if _, ok := av.Get(f.Name); !ok {
+				fieldPath := append(path[:len(path):len(path)], PathElem{Name: f.Name})
+				changes = append(changes, changed(fieldPath, nil, &f.Node))
+			}
+		}
+		return changes
+	case *List:
+		bv := b.Value.(*List)
+		for i := 0; i < len(av.Items) || i < len(bv.Items); i++ {
+			itemPath := append(path[:len(path):len(path)], PathElem{Index: i, IsIndex: true})
+			switch {
+			case i >= len(bv.Items):
+				changes = append(changes, changed(itemPath, &av.Items[i], nil))
+			case i >= len(av.Items):
+				changes = append(changes, changed(itemPath, nil, &bv.Items[i]))
+			default:
+				changes = diff(itemPath, av.Items[i], bv.Items[i], changes)
+			}
+		}
+		return changes
+	}
+
+	if !equal(a, b) {
+		changes = append(changes, changed(path, &a, &b))
+	}
+	return changes
+}
+
+func changed(path Path, a, b *Node) Change {
+	c := Change{Path: path}
+	if a != nil {
+		old := *a
+		c.Old = &old
+	}
+	if b != nil {
+		n := *b
+		c.New = &n
+	}
+	return c
+}
+
+// equal reports whether two tags of the same type, which are neither
+// compounds nor lists, hold the same value.
+func equal(a, b Node) bool {
+	switch av := a.Value.(type) {
+	case float32:
+		return math.Float32bits(av) == math.Float32bits(b.Value.(float32))
+	case float64:
+		return math.Float64bits(av) == math.Float64bits(b.Value.(float64))
+	case []int8:
+		return equalArrays(av, b.Value.([]int8))
+	case []int32:
+		return equalArrays(av, b.Value.([]int32))
+	case []int64:
+		return equalArrays(av, b.Value.([]int64))
+	}
+	return a.Value == b.Value
+}
+
+func equalArrays[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package nbtree_test
+
+import (
+	"testing"
+
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+func TestDiffReportsChangedAddedAndRemovedTags(t *testing.T) {
+	a, err := nbtree.ParseSNBT(`{same:1b,changed:1b,retyped:1b,removed:"x",list:[1,2,3],arr:[I;1,2]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := nbtree.ParseSNBT(`{same:1b,changed:2b,retyped:1s,list:[1,5],arr:[I;1,3],added:{a:1}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"~ changed: 1b -> 2b",
+		"~ retyped: 1b -> 1s",
+		`- removed: "x"`,
+		"~ list[1]: 2 -> 5",
+		"- list[2]: 3",
+		"~ arr: [I;1,2] -> [I;1,3]",
+		"+ added: {a:1}",
+	}
+
+	changes := nbtree.Diff(a, b)
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %v", len(want), changes)
+	}
+
+	for i, c := range changes {
+		if c.String() != want[i] {
+			t.Errorf("expected change %d to be %s, got %s", i, want[i], c)
+		}
+	}
+
+	if changes := nbtree.Diff(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
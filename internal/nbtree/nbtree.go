@@ -0,0 +1,137 @@
+// Package nbtree reads and writes NBT data as a tree of tags, keeping
+// every tag's type and the order of every compound's fields, so data
+// may be edited and written back without losing anything the program
+// doesn't know about.
+package nbtree
+
+import "fmt"
+
+// Tag types as defined by the NBT format.
+const (
+	TagEnd byte = iota
+	TagByte
+	TagShort
+	TagInt
+	TagLong
+	TagFloat
+	TagDouble
+	TagByteArray
+	TagString
+	TagList
+	TagCompound
+	TagIntArray
+	TagLongArray
+)
+
+var tagNames = []string{
+	"end", "byte", "short", "int", "long", "float", "double",
+	"byte_array", "string", "list", "compound", "int_array", "long_array",
+}
+
+// TypeName returns the name of the given tag type.
+func TypeName(typ byte) string {
+	if int(typ) >= len(tagNames) {
+		return fmt.Sprintf("unknown(%d)", typ)
+	}
+	return tagNames[typ]
+}
+
+// Node is a single tag. Value holds an int8, int16, int32, int64, float32,
+// float64, []int8, string, *List, *Compound, []int32 or []int64 depending
+// on Type.
+type Node struct {
+	Type  byte
+	Value any
+}
+
+// Field is a named tag within a compound, or the root tag of a file.
+type Field struct {
+	Name string
+	Node
+}
+
+type Compound struct {
+	Fields []Field
+}
+
+type List struct {
+	// Type is the type of every item within the list.
+	Type  byte
+	Items []Node
+}
+
+func Byte(v int8) Node             { return Node{Type: TagByte, Value: v} }
+func Short(v int16) Node           { return Node{Type: TagShort, Value: v} }
+func Int(v int32) Node             { return Node{Type: TagInt, Value: v} }
+func Long(v int64) Node            { return Node{Type: TagLong, Value: v} }
+func Float(v float32) Node         { return Node{Type: TagFloat, Value: v} }
+func Double(v float64) Node        { return Node{Type: TagDouble, Value: v} }
+func String(v string) Node         { return Node{Type: TagString, Value: v} }
+func NewList(l *List) Node         { return Node{Type: TagList, Value: l} }
+func NewCompound(c *Compound) Node { return Node{Type: TagCompound, Value: c} }
+
+// Compound returns the node's compound, or nil if it isn't one.
+func (n Node) Compound() *Compound {
+	c, _ := n.Value.(*Compound)
+	return c
+}
+
+// List returns the node's list, or nil if it isn't one.
+func (n Node) List() *List {
+	l, _ := n.Value.(*List)
+	return l
+}
+
+// Get returns the field with the given name.
+func (c *Compound) Get(name string) (Node, bool) {
+	for _, f := range c.Fields {
+		if f.Name == name {
+			return f.Node, true
+		}
+	}
+	return Node{}, false
+}
+
+// Set replaces the field with the given name, keeping its position, or
+// appends it if there's no such field.
+func (c *Compound) Set(name string, n Node) {
+	for i, f := range c.Fields {
+		if f.Name == name {
+			c.Fields[i].Node = n
+			return
+		}
+	}
+	c.Fields = append(c.Fields, Field{Name: name, Node: n})
+}
+
+// Delete removes the field with the given name, reporting whether it
+// existed.
+func (c *Compound) Delete(name string) bool {
+	for i, f := range c.Fields {
+		if f.Name == name {
+			c.Fields = append(c.Fields[:i], c.Fields[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Interface returns the node's value as plain Go values, compounds become
+// map[string]any and lists []any, such as for encoding as JSON.
+func (n Node) Interface() any {
+	switch v := n.Value.(type) {
+	case *Compound:
+		m := make(map[string]any, len(v.Fields))
+		for _, f := range v.Fields {
+			m[f.Name] = f.Interface()
+		}
+		return m
+	case *List:
+		items := make([]any, len(v.Items))
+		for i, item := range v.Items {
+			items[i] = item.Interface()
+		}
+		return items
+	}
+	return n.Value
+}
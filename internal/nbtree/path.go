@@ -0,0 +1,259 @@
+package nbtree
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrNotFound = errors.New("tag not found")
+
+// PathElem is either the name of a compound's field or an index into a
+// list or array.
+type PathElem struct {
+	Name    string
+	Index   int
+	IsIndex bool
+}
+
+// Path addresses a tag within a tree, such as Data.GameRules.keepInventory
+// or Inventory[0].id. Names holding dots or brackets may be double quoted.
+type Path []PathElem
+
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, e := range p {
+		if e.IsIndex {
+			sb.WriteString("[" + strconv.Itoa(e.Index) + "]")
+			continue
+		}
+
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+
+		if strings.ContainsAny(e.Name, `.[]"`) || len(e.Name) == 0 {
+			sb.WriteString(strconv.Quote(e.Name))
+			continue
+		}
+		sb.WriteString(e.Name)
+	}
+	return sb.String()
+}
+
+// ParsePath parses a dotted path, an empty path addresses the root.
+func ParsePath(s string) (Path, error) {
+	var path Path
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			if i == 0 || i == len(s)-1 || s[i+1] == '.' || s[i+1] == '[' {
+				return nil, fmt.Errorf("invalid path %s: empty name at %d", s, i)
+			}
+			i++
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %s: unclosed [", s)
+			}
+
+			index, err := strconv.Atoi(s[i+1 : i+end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path %s: invalid index %s", s, s[i+1:i+end])
+			}
+			path = append(path, PathElem{Index: index, IsIndex: true})
+			i += end + 1
+		case '"':
+			unquoted, err := strconv.QuotedPrefix(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %s: %w", s, err)
+			}
+
+			name, _ := strconv.Unquote(unquoted)
+			path = append(path, PathElem{Name: name})
+			i += len(unquoted)
+		default:
+			end := strings.IndexAny(s[i:], ".[")
+			if end < 0 {
+				end = len(s) - i
+			}
+			path = append(path, PathElem{Name: s[i : i+end]})
+			i += end
+		}
+	}
+
+	return path, nil
+}
+
+// Get returns the tag at the given path.
+func Get(root Node, path Path) (Node, error) {
+	n := root
+	for i, e := range path {
+		child, err := child(n, e)
+		if err != nil {
+			return Node{}, fmt.Errorf("%s: %w", path[:i+1], err)
+		}
+		n = child
+	}
+	return n, nil
+}
+
+func child(n Node, e PathElem) (Node, error) {
+	if !e.IsIndex {
+		c := n.Compound()
+		if c == nil {
+			return Node{}, fmt.Errorf("%s is not a compound", TypeName(n.Type))
+		}
+
+		field, ok := c.Get(e.Name)
+		if !ok {
+			return Node{}, ErrNotFound
+		}
+		return field, nil
+	}
+
+	switch v := n.Value.(type) {
+	case *List:
+		if e.Index >= len(v.Items) {
+			return Node{}, ErrNotFound
+		}
+		return v.Items[e.Index], nil
+	case []int8:
+		if e.Index >= len(v) {
+			return Node{}, ErrNotFound
+		}
+		return Byte(v[e.Index]), nil
+	case []int32:
+		if e.Index >= len(v) {
+			return Node{}, ErrNotFound
+		}
+		return Int(v[e.Index]), nil
+	case []int64:
+		if e.Index >= len(v) {
+			return Node{}, ErrNotFound
+		}
+		return Long(v[e.Index]), nil
+	}
+
+	return Node{}, fmt.Errorf("%s is not a list or array", TypeName(n.Type))
+}
+
+// Set replaces the tag at the given path, or adds it if its parent exists.
+// An index one past the end of a list appends to it.
+func Set(root Node, path Path, n Node) error {
+	if len(path) == 0 {
+		return errors.New("unable to replace the root tag")
+	}
+
+	parent, err := Get(root, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+
+	last := path[len(path)-1]
+	if !last.IsIndex {
+		c := parent.Compound()
+		if c == nil {
+			return fmt.Errorf("%s: %s is not a compound", path[:len(path)-1], TypeName(parent.Type))
+		}
+		c.Set(last.Name, n)
+		return nil
+	}
+
+	if err := setIndex(parent, last.Index, n); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func setIndex(parent Node, index int, n Node) error {
+	switch v := parent.Value.(type) {
+	case *List:
+		if len(v.Items) == 0 {
+			v.Type = n.Type
+		}
+
+		if n.Type != v.Type {
+			return fmt.Errorf("unable to put %s within list of %s", TypeName(n.Type), TypeName(v.Type))
+		}
+
+		return setItem(&v.Items, index, n)
+	case []int8:
+		if n.Type != TagByte {
+			return fmt.Errorf("unable to put %s within byte array", TypeName(n.Type))
+		}
+		return setArrayItem(v, index, n.Value.(int8))
+	case []int32:
+		if n.Type != TagInt {
+			return fmt.Errorf("unable to put %s within int array", TypeName(n.Type))
+		}
+		return setArrayItem(v, index, n.Value.(int32))
+	case []int64:
+		if n.Type != TagLong {
+			return fmt.Errorf("unable to put %s within long array", TypeName(n.Type))
+		}
+		return setArrayItem(v, index, n.Value.(int64))
+	}
+
+	return fmt.Errorf("%s is not a list or array", TypeName(parent.Type))
+}
+
+func setItem[T any](items *[]T, index int, v T) error {
+	switch {
+	case index < len(*items):
+		(*items)[index] = v
+	case index == len(*items):
+		*items = append(*items, v)
+	default:
+		return fmt.Errorf("index %d out of range of %d items", index, len(*items))
+	}
+	return nil
+}
+
+// setArrayItem replaces an item of an array in place, arrays are held by
+// value so can't be appended to this way.
+func setArrayItem[T any](array []T, index int, v T) error {
+	if index >= len(array) {
+		return errors.New("unable to append to an array in place, set the whole array instead")
+	}
+	array[index] = v
+	return nil
+}
+
+// Delete removes the tag at the given path.
+func Delete(root Node, path Path) error {
+	if len(path) == 0 {
+		return errors.New("unable to delete the root tag")
+	}
+
+	parent, err := Get(root, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+
+	last := path[len(path)-1]
+	if !last.IsIndex {
+		c := parent.Compound()
+		if c == nil {
+			return fmt.Errorf("%s: %s is not a compound", path[:len(path)-1], TypeName(parent.Type))
+		}
+
+		if !c.Delete(last.Name) {
+			return fmt.Errorf("%s: %w", path, ErrNotFound)
+		}
+		return nil
+	}
+
+	l := parent.List()
+	if l == nil {
+		return fmt.Errorf("%s: only list items may be deleted by index", path)
+	}
+
+	if last.Index >= len(l.Items) {
+		return fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+	l.Items = append(l.Items[:last.Index], l.Items[last.Index+1:]...)
+
+	return nil
+}
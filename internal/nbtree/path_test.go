@@ -0,0 +1,70 @@
+package nbtree_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+func TestPathGetSetDelete(t *testing.T) {
+	root, err := nbtree.ParseSNBT(`{Data:{GameRules:{keepInventory:"false"},"odd.name":[I;1,2]},Inventory:[{Slot:0b,id:"minecraft:dirt"}]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mustPath := func(s string) nbtree.Path {
+		t.Helper()
+		p, err := nbtree.ParsePath(s)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %s: %v", s, err)
+		}
+		return p
+	}
+
+	n, err := nbtree.Get(root, mustPath("Data.GameRules.keepInventory"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v, err := nbtree.ParseValue(n.Type, "true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for path, n := range map[string]nbtree.Node{
+		"Data.GameRules.keepInventory": v,
+		`Data."odd.name"[1]`:           nbtree.Int(5),
+		"Inventory[1]":                 nbtree.NewCompound(&nbtree.Compound{}),
+	} {
+		if err := nbtree.Set(root, mustPath(path), n); err != nil {
+			t.Fatalf("unexpected error setting %s: %v", path, err)
+		}
+	}
+
+	if err := nbtree.Set(root, mustPath("Inventory[0]"), nbtree.Int(1)); err == nil {
+		t.Error("expected an error putting an int within a list of compounds")
+	}
+
+	if err := nbtree.Delete(root, mustPath("Inventory[0]")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := nbtree.Get(root, mustPath("Data.missing")); !errors.Is(err, nbtree.ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	want := `{Data:{GameRules:{keepInventory:"true"},odd.name:[I;1,5]},Inventory:[{}]}`
+	if got := nbtree.FormatSNBT(root, ""); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	again, err := nbtree.ParseSNBT(nbtree.FormatSNBT(root, "  "))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := nbtree.FormatSNBT(again, ""); got != want {
+		t.Errorf("expected pretty SNBT to parse back to %s, got %s", want, got)
+	}
+}
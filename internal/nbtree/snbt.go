@@ -0,0 +1,514 @@
+package nbtree
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FormatSNBT formats the node as stringified NBT, the same syntax used by
+// commands within the game. Compounds and lists are spread over many
+// lines when indent isn't empty.
+func FormatSNBT(n Node, indent string) string {
+	var sb strings.Builder
+	writeSNBT(&sb, n, indent, "")
+	return sb.String()
+}
+
+func writeSNBT(sb *strings.Builder, n Node, indent, prefix string) {
+	switch v := n.Value.(type) {
+	case int8:
+		sb.WriteString(strconv.FormatInt(int64(v), 10) + "b")
+	case int16:
+		sb.WriteString(strconv.FormatInt(int64(v), 10) + "s")
+	case int32:
+		sb.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		sb.WriteString(strconv.FormatInt(v, 10) + "L")
+	case float32:
+		sb.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32) + "f")
+	case float64:
+		sb.WriteString(strconv.FormatFloat(v, 'g', -1, 64) + "d")
+	case string:
+		sb.WriteString(quoteSNBT(v))
+	case []int8:
+		sb.WriteString("[B;")
+		for i, b := range v {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(strconv.FormatInt(int64(b), 10) + "b")
+		}
+		sb.WriteByte(']')
+	case []int32:
+		sb.WriteString("[I;")
+		for i, e := range v {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(strconv.FormatInt(int64(e), 10))
+		}
+		sb.WriteByte(']')
+	case []int64:
+		sb.WriteString("[L;")
+		for i, e := range v {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(strconv.FormatInt(e, 10) + "L")
+		}
+		sb.WriteByte(']')
+	case *List:
+		sb.WriteByte('[')
+		for i, item := range v.Items {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			newline(sb, indent, prefix+indent)
+			writeSNBT(sb, item, indent, prefix+indent)
+		}
+		if len(v.Items) > 0 {
+			newline(sb, indent, prefix)
+		}
+		sb.WriteByte(']')
+	case *Compound:
+		sb.WriteByte('{')
+		for i, f := range v.Fields {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			newline(sb, indent, prefix+indent)
+			sb.WriteString(formatName(f.Name))
+			sb.WriteByte(':')
+			if len(indent) > 0 {
+				sb.WriteByte(' ')
+			}
+			writeSNBT(sb, f.Node, indent, prefix+indent)
+		}
+		if len(v.Fields) > 0 {
+			newline(sb, indent, prefix)
+		}
+		sb.WriteByte('}')
+	}
+}
+
+func newline(sb *strings.Builder, indent, prefix string) {
+	if len(indent) == 0 {
+		return
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(prefix)
+}
+
+var unquotedPattern = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+
+func formatName(name string) string {
+	if unquotedPattern.MatchString(name) {
+		return name
+	}
+	return quoteSNBT(name)
+}
+
+func quoteSNBT(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// ParseSNBT parses a single value of stringified NBT, such as
+// {Count:1b,id:"minecraft:torch"}, 20.0f or [I;1,2,3].
+func ParseSNBT(s string) (Node, error) {
+	p := snbtParser{s: s}
+	n, err := p.value(0)
+	if err != nil {
+		return Node{}, err
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return Node{}, p.errorf("unexpected trailing data")
+	}
+
+	return n, nil
+}
+
+var (
+	bytePattern      = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)b$`)
+	shortPattern     = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)s$`)
+	intPattern       = regexp.MustCompile(`^[-+]?(?:0|[1-9][0-9]*)$`)
+	longPattern      = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)l$`)
+	floatPattern     = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?f$`)
+	doublePattern    = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?d$`)
+	doubleNoSuffixed = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?$`)
+)
+
+type snbtParser struct {
+	s   string
+	pos int
+}
+
+func (p *snbtParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("invalid SNBT at position %d: %s", p.pos, fmt.Sprintf(format, a...))
+}
+
+func (p *snbtParser) skipSpace() {
+	for p.pos < len(p.s) && strings.ContainsRune(" \t\r\n", rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *snbtParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *snbtParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *snbtParser) value(depth int) (Node, error) {
+	if depth > maxDepth {
+		return Node{}, p.errorf("tags nested deeper than %d", maxDepth)
+	}
+
+	switch p.peek() {
+	case '{':
+		return p.compound(depth)
+	case '[':
+		return p.listOrArray(depth)
+	case '"', '\'':
+		s, err := p.quoted()
+		return String(s), err
+	case 0:
+		return Node{}, p.errorf("expected a value")
+	}
+
+	token := p.unquoted()
+	if len(token) == 0 {
+		return Node{}, p.errorf("expected a value")
+	}
+
+	return scalar(token), nil
+}
+
+// scalar infers a tag from an unquoted token the same way the game does,
+// anything which isn't a number or boolean is a string.
+func scalar(token string) Node {
+	trimSuffix := func() string { return token[:len(token)-1] }
+
+	switch {
+	case bytePattern.MatchString(token):
+		if v, err := strconv.ParseInt(trimSuffix(), 10, 8); err == nil {
+			return Byte(int8(v))
+		}
+	case shortPattern.MatchString(token):
+		if v, err := strconv.ParseInt(trimSuffix(), 10, 16); err == nil {
+			return Short(int16(v))
+		}
+	case longPattern.MatchString(token):
+		if v, err := strconv.ParseInt(trimSuffix(), 10, 64); err == nil {
+			return Long(v)
+		}
+	case intPattern.MatchString(token):
+		if v, err := strconv.ParseInt(token, 10, 32); err == nil {
+			return Int(int32(v))
+		}
+	case floatPattern.MatchString(token):
+		if v, err := strconv.ParseFloat(trimSuffix(), 32); err == nil {
+			return Float(float32(v))
+		}
+	case doublePattern.MatchString(token):
+		if v, err := strconv.ParseFloat(trimSuffix(), 64); err == nil {
+			return Double(v)
+		}
+	case doubleNoSuffixed.MatchString(token):
+		if v, err := strconv.ParseFloat(token, 64); err == nil {
+			return Double(v)
+		}
+	case strings.EqualFold(token, "true"):
+		return Byte(1)
+	case strings.EqualFold(token, "false"):
+		return Byte(0)
+	}
+
+	return String(token)
+}
+
+func (p *snbtParser) unquoted() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && unquotedPattern.MatchString(p.s[p.pos:p.pos+1]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *snbtParser) quoted() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.s) {
+				return "", p.errorf("unterminated escape")
+			}
+			sb.WriteByte(p.s[p.pos])
+			p.pos++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+func (p *snbtParser) name() (string, error) {
+	switch p.peek() {
+	case '"', '\'':
+		return p.quoted()
+	}
+
+	name := p.unquoted()
+	if len(name) == 0 {
+		return "", p.errorf("expected a name")
+	}
+	return name, nil
+}
+
+func (p *snbtParser) compound(depth int) (Node, error) {
+	p.pos++ // {
+
+	c := &Compound{}
+	if p.peek() == '}' {
+		p.pos++
+		return NewCompound(c), nil
+	}
+
+	for {
+		name, err := p.name()
+		if err != nil {
+			return Node{}, err
+		}
+
+		if err := p.expect(':'); err != nil {
+			return Node{}, err
+		}
+
+		n, err := p.value(depth + 1)
+		if err != nil {
+			return Node{}, err
+		}
+		c.Set(name, n)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return NewCompound(c), nil
+		default:
+			return Node{}, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (p *snbtParser) listOrArray(depth int) (Node, error) {
+	p.pos++ // [
+
+	// arrays are prefixed by their type, such as [I;1,2,3]
+	if p.pos+1 < len(p.s) && p.s[p.pos+1] == ';' {
+		return p.array(depth)
+	}
+
+	l := &List{Type: TagEnd}
+	if p.peek() == ']' {
+		p.pos++
+		return NewList(l), nil
+	}
+
+	for {
+		n, err := p.value(depth + 1)
+		if err != nil {
+			return Node{}, err
+		}
+
+		if len(l.Items) == 0 {
+			l.Type = n.Type
+		} else if n.Type != l.Type {
+			return Node{}, p.errorf("%s within list of %s", TypeName(n.Type), TypeName(l.Type))
+		}
+		l.Items = append(l.Items, n)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return NewList(l), nil
+		default:
+			return Node{}, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *snbtParser) array(depth int) (Node, error) {
+	prefix := p.s[p.pos]
+	p.pos += 2 // B; I; or L;
+
+	var elemType byte
+	switch prefix {
+	case 'B':
+		elemType = TagByte
+	case 'I':
+		elemType = TagInt
+	case 'L':
+		elemType = TagLong
+	default:
+		return Node{}, p.errorf("unknown array type %q", prefix)
+	}
+
+	var items []Node
+	if p.peek() == ']' {
+		p.pos++
+	} else {
+		for {
+			n, err := p.value(depth + 1)
+			if err != nil {
+				return Node{}, err
+			}
+
+			// ints are accepted within byte and long arrays without
+			// their suffix
+			if n.Type == TagInt && elemType != TagInt {
+				n, err = ParseValue(elemType, strconv.Itoa(int(n.Value.(int32))))
+				if err != nil {
+					return Node{}, p.errorf("%v", err)
+				}
+			}
+
+			if n.Type != elemType {
+				return Node{}, p.errorf("%s within %s array", TypeName(n.Type), TypeName(elemType))
+			}
+			items = append(items, n)
+
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+
+			if err := p.expect(']'); err != nil {
+				return Node{}, err
+			}
+			break
+		}
+	}
+
+	switch elemType {
+	case TagByte:
+		v := make([]int8, len(items))
+		for i, n := range items {
+			v[i] = n.Value.(int8)
+		}
+		return Node{Type: TagByteArray, Value: v}, nil
+	case TagInt:
+		v := make([]int32, len(items))
+		for i, n := range items {
+			v[i] = n.Value.(int32)
+		}
+		return Node{Type: TagIntArray, Value: v}, nil
+	}
+
+	v := make([]int64, len(items))
+	for i, n := range items {
+		v[i] = n.Value.(int64)
+	}
+	return Node{Type: TagLongArray, Value: v}, nil
+}
+
+// ParseValue parses s as a tag of the given type. Numbers may be given
+// with or without their SNBT suffix, bytes also accept true and false.
+// Lists, compounds and arrays are parsed as SNBT.
+func ParseValue(typ byte, s string) (Node, error) {
+	num := strings.TrimSpace(s)
+	if len(num) > 0 && typ >= TagByte && typ <= TagDouble {
+		if last := num[len(num)-1] | 0x20; strings.IndexByte("bslfd", last) >= 0 {
+			num = num[:len(num)-1]
+		}
+	}
+
+	var (
+		n   Node
+		err error
+	)
+
+	switch typ {
+	case TagByte:
+		switch strings.ToLower(num) {
+		case "true":
+			return Byte(1), nil
+		case "false":
+			return Byte(0), nil
+		}
+
+		var v int64
+		v, err = strconv.ParseInt(num, 10, 8)
+		n = Byte(int8(v))
+	case TagShort:
+		var v int64
+		v, err = strconv.ParseInt(num, 10, 16)
+		n = Short(int16(v))
+	case TagInt:
+		var v int64
+		v, err = strconv.ParseInt(num, 10, 32)
+		n = Int(int32(v))
+	case TagLong:
+		var v int64
+		v, err = strconv.ParseInt(num, 10, 64)
+		n = Long(v)
+	case TagFloat:
+		var v float64
+		v, err = strconv.ParseFloat(num, 32)
+		n = Float(float32(v))
+	case TagDouble:
+		var v float64
+		v, err = strconv.ParseFloat(num, 64)
+		n = Double(v)
+	case TagString:
+		return String(s), nil
+	default:
+		n, err = ParseSNBT(s)
+		if err == nil && n.Type != typ {
+			err = fmt.Errorf("got %s", TypeName(n.Type))
+		}
+	}
+
+	if err != nil {
+		return Node{}, fmt.Errorf("invalid %s value %s: %w", TypeName(typ), s, err)
+	}
+
+	return n, nil
+}
+
+// ParseType returns the tag type with the given name, such as int or
+// byte_array.
+func ParseType(name string) (byte, error) {
+	for i, n := range tagNames {
+		if i != int(TagEnd) && strings.EqualFold(n, name) {
+			return byte(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tag type %s", name)
+}
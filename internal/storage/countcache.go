@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+// BlocksCountCache returns the cache of the given world's block counts, for
+// scans to only decode the chunks saved since the world was last scanned.
+func (db DB) BlocksCountCache(world string) mc.BlocksCountCache {
+	return blocksCountCache{db: db, world: world}
+}
+
+// ClearBlocksCountCache drops the cache of the given world's block counts,
+// so that every chunk is decoded again by the next scan.
+func (db DB) ClearBlocksCountCache(world string) error {
+	return db.conn.DropPrefix([]byte(cachePrefix + worldEscaper.Replace(world) + "/"))
+}
+
+type blocksCountCache struct {
+	db    DB
+	world string
+}
+
+func (c blocksCountCache) regionKey(dim string, pos mc.RegionPos) Key {
+	return Key{Kind: CachedRegionKey, World: c.world, Dimension: dim, Region: pos}
+}
+
+func (c blocksCountCache) chunkKey(dim string, pos mc.RegionPos, chunk mc.ChunkPos) Key {
+	return Key{Kind: CachedChunkKey, World: c.world, Dimension: dim, Region: pos, Chunk: chunk}
+}
+
+func (c blocksCountCache) Region(dim string, pos mc.RegionPos) (mc.RegionCounts, bool, error) {
+	var (
+		region mc.RegionCounts
+		ok     bool
+	)
+	err := c.db.conn.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(c.regionKey(dim, pos).Bytes())
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ok = true
+		return item.Value(func(v []byte) error {
+			region, err = decodeRegionCounts(v)
+			return err
+		})
+	})
+
+	return region, ok, err
+}
+
+func (c blocksCountCache) Chunks(dim string, pos mc.RegionPos) (map[mc.ChunkPos]mc.ChunkCounts, error) {
+	prefix := append(c.regionKey(dim, pos).Bytes(), '/')
+	chunks := map[mc.ChunkPos]mc.ChunkCounts{}
+	err := c.db.conn.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: 100, Prefix: prefix})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := ParseKey(item.Key())
+			if key.Kind != CachedChunkKey {
+				return fmt.Errorf("invalid cache key %s", item.Key())
+			}
+			chunk := key.Chunk
+
+			err := item.Value(func(v []byte) (err error) {
+				chunks[chunk], err = decodeChunkCounts(v)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+func (c blocksCountCache) PutRegion(dim string, pos mc.RegionPos, region mc.RegionCounts, chunks map[mc.ChunkPos]mc.ChunkCounts) error {
+	existing, err := c.Chunks(dim, pos)
+	if err != nil {
+		return err
+	}
+
+	// a region holds too many chunks for a single transaction
+	wb := c.db.conn.NewWriteBatch()
+	defer wb.Cancel()
+
+	for chunk := range existing {
+		if _, ok := chunks[chunk]; !ok {
+			if err := wb.Delete(c.chunkKey(dim, pos, chunk).Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	for chunk, counts := range chunks {
+		if err := wb.Set(c.chunkKey(dim, pos, chunk).Bytes(), encodeChunkCounts(counts)); err != nil {
+			return err
+		}
+	}
+
+	if err := wb.Set(c.regionKey(dim, pos).Bytes(), encodeRegionCounts(region)); err != nil {
+		return err
+	}
+
+	return wb.Flush()
+}
+
+// encodeRegionCounts lays out the region's modification time and size as
+// varints, followed by its counts.
+func encodeRegionCounts(r mc.RegionCounts) []byte {
+	buf := binary.AppendVarint(nil, r.ModTime.UnixNano())
+	buf = binary.AppendVarint(buf, r.Size)
+	return appendCounts(buf, r.Counts)
+}
+
+func decodeRegionCounts(data []byte) (mc.RegionCounts, error) {
+	modTime, n := binary.Varint(data)
+	if n <= 0 {
+		return mc.RegionCounts{}, errors.New("invalid cached region counts")
+	}
+	data = data[n:]
+
+	size, n := binary.Varint(data)
+	if n <= 0 {
+		return mc.RegionCounts{}, errors.New("invalid cached region counts")
+	}
+
+	counts, err := readCounts(data[n:])
+	if err != nil {
+		return mc.RegionCounts{}, fmt.Errorf("invalid cached region counts: %w", err)
+	}
+
+	return mc.RegionCounts{ModTime: time.Unix(0, modTime).UTC(), Size: size, Counts: counts}, nil
+}
+
+// encodeChunkCounts lays out the chunk's timestamp as a varint, followed by
+// its counts.
+func encodeChunkCounts(c mc.ChunkCounts) []byte {
+	return appendCounts(binary.AppendVarint(nil, int64(c.Timestamp)), c.Counts)
+}
+
+func decodeChunkCounts(data []byte) (mc.ChunkCounts, error) {
+	ts, n := binary.Varint(data)
+	if n <= 0 {
+		return mc.ChunkCounts{}, errors.New("invalid cached chunk counts")
+	}
+
+	counts, err := readCounts(data[n:])
+	if err != nil {
+		return mc.ChunkCounts{}, fmt.Errorf("invalid cached chunk counts: %w", err)
+	}
+
+	return mc.ChunkCounts{Timestamp: int32(ts), Counts: counts}, nil
+}
+
+// appendCounts lays out the number of counts, then each block ID's length,
+// the ID itself and its count, as uvarints sorted by ID.
+func appendCounts(buf []byte, counts map[string]uint64) []byte {
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	buf = binary.AppendUvarint(buf, uint64(len(ids)))
+	for _, id := range ids {
+		buf = binary.AppendUvarint(buf, uint64(len(id)))
+		buf = append(buf, id...)
+		buf = binary.AppendUvarint(buf, counts[id])
+	}
+	return buf
+}
+
+func readCounts(data []byte) (map[string]uint64, error) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errors.New("missing number of counts")
+	}
+	data = data[n:]
+
+	// each count takes at least a byte, so however many are claimed there
+	// can't be more than there is data left
+	hint := size
+	if hint > uint64(len(data)) {
+		hint = uint64(len(data))
+	}
+
+	counts := make(map[string]uint64, hint)
+	for i := uint64(0); i < size; i++ {
+		l, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < l {
+			return nil, errors.New("truncated block ID")
+		}
+		id := string(data[n : n+int(l)])
+		data = data[n+int(l):]
+
+		count, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("truncated count")
+		}
+		data = data[n:]
+		counts[id] = count
+	}
+
+	return counts, nil
+}
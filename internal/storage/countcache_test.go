@@ -0,0 +1,91 @@
+package storage_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/tauraamui/mcscan/internal/storage"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestBlocksCountCacheReplacesRegionsChunks(t *testing.T) {
+	db, err := storage.NewMemDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	cache := db.BlocksCountCache("world")
+	pos := mc.RegionPos{X: -1, Z: 2}
+	region := mc.RegionCounts{ModTime: time.Date(2023, 6, 1, 22, 0, 0, 5, time.UTC), Size: 8192, Counts: map[string]uint64{"minecraft:stone": 5}}
+	chunks := map[mc.ChunkPos]mc.ChunkCounts{
+		{X: -32, Z: 64}: {Timestamp: 1685656800, Counts: map[string]uint64{"minecraft:stone": 3}},
+		{X: -1, Z: 95}:  {Timestamp: 1685656801, Counts: map[string]uint64{"minecraft:stone": 2}},
+	}
+
+	for _, dim := range []string{"custom:mining/deep", mc.Overworld} {
+		if err := cache.PutRegion(dim, pos, region, chunks); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	delete(chunks, mc.ChunkPos{X: -1, Z: 95})
+	if err := cache.PutRegion(mc.Overworld, pos, region, chunks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, ok, err := cache.Region(mc.Overworld, pos)
+	if err != nil || !ok {
+		t.Fatalf("expected cached region, got %v %v", ok, err)
+	}
+
+	if !got.ModTime.Equal(region.ModTime) || got.Size != region.Size || !reflect.DeepEqual(got.Counts, region.Counts) {
+		t.Errorf("expected %+v, got %+v", region, got)
+	}
+
+	gotChunks, err := cache.Chunks(mc.Overworld, pos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(gotChunks, chunks) {
+		t.Errorf("expected %+v, got %+v", chunks, gotChunks)
+	}
+
+	if deep, _ := cache.Chunks("custom:mining/deep", pos); len(deep) != 2 {
+		t.Errorf("expected each dimension to be cached apart, got %+v", deep)
+	}
+
+	if err := db.ClearBlocksCountCache("world"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok, _ := cache.Region(mc.Overworld, pos); ok {
+		t.Error("expected the cache to be cleared")
+	}
+}
+
+func TestBlocksCountCacheRejectsImplausibleCounts(t *testing.T) {
+	db, err := storage.NewMemDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	// a region's mod time and size, then a claim of 2^62 counts with none
+	// following
+	value := []byte{0, 0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40}
+	key := storage.Key{Kind: storage.CachedRegionKey, World: "world", Dimension: mc.Overworld}
+	err = db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key.Bytes(), value)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := db.BlocksCountCache("world").Region(mc.Overworld, mc.RegionPos{}); err == nil {
+		t.Error("expected an error reading truncated counts")
+	}
+}
@@ -2,9 +2,6 @@ package storage
 
 import (
 	"encoding/binary"
-	"fmt"
-	"io"
-	"os"
 	"time"
 
 	"github.com/dgraph-io/badger/v3"
@@ -14,21 +11,61 @@ type DB struct {
 	conn *badger.DB
 }
 
-func NewDB(db *badger.DB) (DB, error) {
-	return newDB(false)
+// Option configures how a database is opened.
+type Option func(opts *badger.Options)
+
+// ReadOnly opens the database only to be read, which many processes may do
+// at once. The database must already exist at the current schema version.
+func ReadOnly() Option {
+	return func(opts *badger.Options) {
+		*opts = opts.WithReadOnly(true)
+	}
+}
+
+// WithSyncWrites makes each write wait until it's been synced to disk, so
+// that it survives the machine crashing rather than only the process.
+func WithSyncWrites() Option {
+	return func(opts *badger.Options) {
+		*opts = opts.WithSyncWrites(true)
+	}
+}
+
+// WithValueLogFileSize sets the size in bytes of each of the files values
+// are logged to, from 1MB up to 2GB, by default 1GB.
+func WithValueLogFileSize(size int64) Option {
+	return func(opts *badger.Options) {
+		*opts = opts.WithValueLogFileSize(size)
+	}
+}
+
+// NewDB opens the database within the given directory, creating it if need
+// be, and migrating it to the current schema version.
+func NewDB(dir string, opts ...Option) (DB, error) {
+	return newDB(badger.DefaultOptions(dir), opts)
 }
 
-func NewMemDB() (DB, error) {
-	return newDB(true)
+func NewMemDB(opts ...Option) (DB, error) {
+	return newDB(badger.DefaultOptions("").WithInMemory(true), opts)
 }
 
-func newDB(inMemory bool) (DB, error) {
-	db, err := badger.Open(badger.DefaultOptions("").WithLogger(nil).WithInMemory(inMemory))
+func newDB(options badger.Options, opts []Option) (DB, error) {
+	options = options.WithLogger(nil)
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	conn, err := badger.Open(options)
 	if err != nil {
 		return DB{}, err
 	}
 
-	return DB{conn: db}, nil
+	db := DB{conn: conn}
+	if err := db.migrate(); err != nil {
+		conn.Close()
+		return DB{}, err
+	}
+
+	return db, nil
 }
 
 func (db DB) Adder(key []byte) *badger.MergeOperator {
@@ -52,27 +89,6 @@ func (db DB) Update(f func(txn *badger.Txn) error) error {
 	return db.conn.Update(f)
 }
 
-func (db DB) DumpTo(w io.Writer) error {
-	return db.conn.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchSize = 10
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			k := item.Key()
-			err := item.Value(func(v []byte) error {
-				fmt.Fprintf(w, "key=%s, value=%d\n", k, bytesToUint64(v))
-				return nil
-			})
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-}
-
 func uint64ToBytes(i uint64) []byte {
 	var buf [8]byte
 	binary.BigEndian.PutUint64(buf[:], i)
@@ -83,10 +99,6 @@ func bytesToUint64(b []byte) uint64 {
 	return binary.BigEndian.Uint64(b)
 }
 
-func (db DB) DumpToStdout() error {
-	return db.DumpTo(os.Stdout)
-}
-
 func (db DB) Close() error {
 	return db.conn.Close()
 }
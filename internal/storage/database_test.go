@@ -0,0 +1,127 @@
+package storage_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/tauraamui/mcscan/internal/storage"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestNewDBReopensAndReadsBack(t *testing.T) {
+	dir := t.TempDir()
+	db, err := storage.NewDB(dir, storage.WithSyncWrites(), storage.WithValueLogFileSize(1<<20))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scan := storage.Scan{World: "world", Time: time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC), Counts: map[string]map[string]uint64{
+		"minecraft:overworld": {"minecraft:stone": 100},
+	}}
+	if err := db.PutScan(scan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db, err = storage.NewDB(dir, storage.ReadOnly())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil || version != storage.SchemaVersion {
+		t.Errorf("expected schema version %d, got %d %v", storage.SchemaVersion, version, err)
+	}
+
+	scans, err := db.Scans(storage.ScanQuery{World: "world"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(scans) != 1 || !scans[0].Time.Equal(scan.Time) || !reflect.DeepEqual(scans[0].Counts, scan.Counts) {
+		t.Errorf("expected %+v, got %+v", scan, scans)
+	}
+
+	if err := db.PutScan(scan); err == nil {
+		t.Error("expected writing to a read only database to fail")
+	}
+}
+
+// setSchemaVersion records the given schema version within the database in dir.
+func setSchemaVersion(t *testing.T, dir string, version uint64) {
+	t.Helper()
+
+	db, err := storage.NewDB(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(txn *badger.Txn) error {
+		if version == 0 {
+			return txn.Delete([]byte("meta/schema"))
+		}
+		return txn.Set([]byte("meta/schema"), []byte{0, 0, 0, 0, 0, 0, 0, byte(version)})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewDBMigratesOlderSchemas(t *testing.T) {
+	dir := t.TempDir()
+	setSchemaVersion(t, dir, 0)
+
+	if _, err := storage.NewDB(dir, storage.ReadOnly()); !errors.Is(err, storage.ErrSchemaOutdated) {
+		t.Fatalf("expected outdated schema error, got %v", err)
+	}
+
+	db, err := storage.NewDB(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if version, _ := db.SchemaVersion(); version != storage.SchemaVersion {
+		t.Errorf("expected migration to schema version %d, got %d", storage.SchemaVersion, version)
+	}
+	db.Close()
+
+	setSchemaVersion(t, dir, storage.SchemaVersion+1)
+	if _, err := storage.NewDB(dir); !errors.Is(err, storage.ErrSchemaTooNew) {
+		t.Errorf("expected schema too new error, got %v", err)
+	}
+}
+
+func TestNewDBDropsCountsCachedByWorldName(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := storage.NewDB(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	region := mc.RegionCounts{Counts: map[string]uint64{"minecraft:stone": 1}}
+	if err := db.BlocksCountCache("world").PutRegion(mc.Overworld, mc.RegionPos{}, region, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db.Close()
+
+	setSchemaVersion(t, dir, 1)
+
+	db, err = storage.NewDB(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	if _, ok, err := db.BlocksCountCache("world").Region(mc.Overworld, mc.RegionPos{}); ok || err != nil {
+		t.Errorf("expected counts cached by world name to be dropped, got %v %v", ok, err)
+	}
+}
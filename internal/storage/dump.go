@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+// DumpFormat is how DumpTo writes each entry of the database.
+type DumpFormat int
+
+const (
+	// DumpText writes each entry on its own line as readable key=value pairs.
+	DumpText DumpFormat = iota
+	// DumpJSON writes each entry on its own line as a JSON object.
+	DumpJSON
+)
+
+// dumpEntry is a key of the database and its value, decoded as its kind.
+// Unknown entries, and those whose value can't be decoded, are dumped as
+// their raw key and hex encoded value.
+type dumpEntry struct {
+	Kind      KeyKind           `json:"kind"`
+	Key       string            `json:"key,omitempty"`
+	World     string            `json:"world,omitempty"`
+	Time      *time.Time        `json:"time,omitempty"`
+	Dimension string            `json:"dimension,omitempty"`
+	Block     string            `json:"block,omitempty"`
+	Region    *mc.RegionPos     `json:"region,omitempty"`
+	Chunk     *mc.ChunkPos      `json:"chunk,omitempty"`
+	Version   *uint64           `json:"version,omitempty"`
+	Count     *uint64           `json:"count,omitempty"`
+	ModTime   *time.Time        `json:"modTime,omitempty"`
+	Size      *int64            `json:"size,omitempty"`
+	Timestamp *time.Time        `json:"timestamp,omitempty"`
+	Counts    map[string]uint64 `json:"counts,omitempty"`
+	Value     string            `json:"value,omitempty"`
+}
+
+func decodeEntry(key, value []byte) dumpEntry {
+	k := ParseKey(key)
+	e := dumpEntry{Kind: k.Kind, World: k.World, Dimension: k.Dimension, Block: k.Block}
+
+	switch k.Kind {
+	case SchemaKey, ScanCountKey:
+		if len(value) != 8 {
+			break
+		}
+
+		v := bytesToUint64(value)
+		if k.Kind == SchemaKey {
+			e.Version = &v
+			return e
+		}
+		e.Time, e.Count = &k.Time, &v
+		return e
+	case CachedRegionKey:
+		r, err := decodeRegionCounts(value)
+		if err != nil {
+			break
+		}
+
+		e.Region, e.ModTime, e.Size, e.Counts = &k.Region, &r.ModTime, &r.Size, r.Counts
+		return e
+	case CachedChunkKey:
+		c, err := decodeChunkCounts(value)
+		if err != nil {
+			break
+		}
+
+		ts := time.Unix(int64(c.Timestamp), 0).UTC()
+		e.Region, e.Chunk, e.Timestamp, e.Counts = &k.Region, &k.Chunk, &ts, c.Counts
+		return e
+	case ScanChunkKey:
+		counts, err := readCounts(value)
+		if err != nil {
+			break
+		}
+
+		e.Time, e.Chunk, e.Counts = &k.Time, &k.Chunk, counts
+		return e
+	}
+
+	return dumpEntry{Kind: UnknownKey, Key: string(key), Value: hex.EncodeToString(value)}
+}
+
+func (e dumpEntry) String() string {
+	var total uint64
+	for _, n := range e.Counts {
+		total += n
+	}
+
+	switch e.Kind {
+	case SchemaKey:
+		return fmt.Sprintf("schema version=%d", *e.Version)
+	case ScanCountKey:
+		return fmt.Sprintf("scan world=%s time=%s dimension=%s block=%s count=%d",
+			e.World, e.Time.Format(time.RFC3339Nano), e.Dimension, e.Block, *e.Count)
+	case CachedRegionKey:
+		return fmt.Sprintf("cached region world=%s dimension=%s region=%d,%d modtime=%s size=%d blocks=%d",
+			e.World, e.Dimension, e.Region.X, e.Region.Z, e.ModTime.Format(time.RFC3339Nano), *e.Size, total)
+	case CachedChunkKey:
+		return fmt.Sprintf("cached chunk world=%s dimension=%s chunk=%d,%d timestamp=%s blocks=%d",
+			e.World, e.Dimension, e.Chunk.X, e.Chunk.Z, e.Timestamp.Format(time.RFC3339), total)
+	case ScanChunkKey:
+		return fmt.Sprintf("scan chunk world=%s time=%s dimension=%s chunk=%d,%d blocks=%d",
+			e.World, e.Time.Format(time.RFC3339Nano), e.Dimension, e.Chunk.X, e.Chunk.Z, total)
+	}
+	return fmt.Sprintf("unknown key=%q value=%s", e.Key, e.Value)
+}
+
+// DumpTo writes every entry of the database to w in the given format, with
+// each key and value decoded as its kind.
+func (db DB) DumpTo(w io.Writer, format DumpFormat) error {
+	enc := json.NewEncoder(w)
+	return db.conn.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			k := item.Key()
+			err := item.Value(func(v []byte) error {
+				e := decodeEntry(k, v)
+				if format == DumpJSON {
+					return enc.Encode(e)
+				}
+				_, err := fmt.Fprintln(w, e)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (db DB) DumpToStdout() error {
+	return db.DumpTo(os.Stdout, DumpText)
+}
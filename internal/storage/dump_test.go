@@ -0,0 +1,70 @@
+package storage_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tauraamui/mcscan/internal/storage"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestDBDumpToDecodesEachKind(t *testing.T) {
+	db, err := storage.NewMemDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	scanned := time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC)
+	if err := db.PutScan(storage.Scan{World: "world", Time: scanned, Counts: map[string]map[string]uint64{
+		mc.Overworld: {"minecraft:stone": 100},
+	}, Chunks: map[string]map[mc.ChunkPos]map[string]uint64{
+		mc.Overworld: {{X: 1, Z: 2}: {"minecraft:stone": 100}},
+	}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	region := mc.RegionCounts{ModTime: scanned, Size: 8192, Counts: map[string]uint64{"minecraft:stone": 100}}
+	chunks := map[mc.ChunkPos]mc.ChunkCounts{{X: 1, Z: 2}: {Timestamp: int32(scanned.Unix()), Counts: region.Counts}}
+	if err := db.BlocksCountCache("world").PutRegion(mc.Overworld, mc.RegionPos{}, region, chunks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var text bytes.Buffer
+	if err := db.DumpTo(&text, storage.DumpText); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"cached region world=world dimension=minecraft:overworld region=0,0 modtime=2023-06-01T22:00:00Z size=8192 blocks=100",
+		"cached chunk world=world dimension=minecraft:overworld chunk=1,2 timestamp=2023-06-01T22:00:00Z blocks=100",
+		"scan chunk world=world time=2023-06-01T22:00:00Z dimension=minecraft:overworld chunk=1,2 blocks=100",
+		"schema version=2",
+		"scan world=world time=2023-06-01T22:00:00Z dimension=minecraft:overworld block=minecraft:stone count=100",
+	}, "\n") + "\n"
+	if text.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, text.String())
+	}
+
+	var js bytes.Buffer
+	if err := db.DumpTo(&js, storage.DumpJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(js.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 JSON entries, got %d", len(lines))
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[4]), &entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if entry["kind"] != "scan" || entry["block"] != "minecraft:stone" || entry["count"] != float64(100) {
+		t.Errorf("unexpected scan entry %v", entry)
+	}
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+// KeyKind is what a key of the database holds.
+type KeyKind int
+
+const (
+	UnknownKey KeyKind = iota
+	// SchemaKey holds the schema version as a uint64.
+	SchemaKey
+	// ScanCountKey holds the count of a block within a dimension as of a
+	// scan, as a uint64, laid out as scan/<world>/<time>/<dimension>/<block>.
+	ScanCountKey
+	// CachedRegionKey holds the cached counts of a region, laid out as
+	// cache/<world>/<dimension>/r.<x>.<z>.
+	CachedRegionKey
+	// CachedChunkKey holds the cached counts of a chunk, laid out beneath
+	// its region as cache/<world>/<dimension>/r.<x>.<z>/<x>.<z>.
+	CachedChunkKey
+	// ScanChunkKey holds the counts of a chunk as of a scan, laid out as
+	// chunks/<world>/<time>/<dimension>/<x>.<z>.
+	ScanChunkKey
+)
+
+// World identifiers are escaped within keys, as they may be paths.
+var worldEscaper = strings.NewReplacer("%", "%25", "/", "%2F")
+
+func (k KeyKind) String() string {
+	switch k {
+	case SchemaKey:
+		return "schema"
+	case ScanCountKey:
+		return "scan"
+	case CachedRegionKey:
+		return "cached region"
+	case CachedChunkKey:
+		return "cached chunk"
+	case ScanChunkKey:
+		return "scan chunk"
+	}
+	return "unknown"
+}
+
+func (k KeyKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+const (
+	scanPrefix      = "scan/"
+	cachePrefix     = "cache/"
+	scanChunkPrefix = "chunks/"
+)
+
+// Key is a key of the database split into its parts, only the parts its
+// kind is laid out with are set.
+type Key struct {
+	Kind      KeyKind
+	World     string
+	Time      time.Time
+	Dimension string
+	Block     string
+	Region    mc.RegionPos
+	Chunk     mc.ChunkPos
+}
+
+// Bytes lays out the key as held within the database, unknown keys have
+// no layout and so are nil.
+func (k Key) Bytes() []byte {
+	switch k.Kind {
+	case SchemaKey:
+		return []byte("meta/schema")
+	case ScanCountKey:
+		return []byte(scanPrefix + worldEscaper.Replace(k.World) + "/" + k.Time.UTC().Format(ScanTimeLayout) + "/" + k.Dimension + "/" + k.Block)
+	case CachedRegionKey:
+		return []byte(fmt.Sprintf("%s%s/%s/r.%d.%d", cachePrefix, worldEscaper.Replace(k.World), k.Dimension, k.Region.X, k.Region.Z))
+	case CachedChunkKey:
+		return []byte(fmt.Sprintf("%s%s/%s/r.%d.%d/%d.%d", cachePrefix, worldEscaper.Replace(k.World), k.Dimension, k.Region.X, k.Region.Z, k.Chunk.X, k.Chunk.Z))
+	case ScanChunkKey:
+		return []byte(fmt.Sprintf("%s%s/%s/%s/%d.%d", scanChunkPrefix, worldEscaper.Replace(k.World), k.Time.UTC().Format(ScanTimeLayout), k.Dimension, k.Chunk.X, k.Chunk.Z))
+	}
+	return nil
+}
+
+// ParseKey splits a key of the database into its parts, any key which isn't
+// laid out as one of the known kinds is an UnknownKey.
+func ParseKey(key []byte) Key {
+	switch {
+	case bytes.Equal(key, Key{Kind: SchemaKey}.Bytes()):
+		return Key{Kind: SchemaKey}
+	case bytes.HasPrefix(key, []byte(scanPrefix)):
+		if k, ok := parseScanKey(string(key[len(scanPrefix):])); ok {
+			return k
+		}
+	case bytes.HasPrefix(key, []byte(cachePrefix)):
+		if k, ok := parseCacheKey(string(key[len(cachePrefix):])); ok {
+			return k
+		}
+	case bytes.HasPrefix(key, []byte(scanChunkPrefix)):
+		if k, ok := parseScanChunkKey(string(key[len(scanChunkPrefix):])); ok {
+			return k
+		}
+	}
+	return Key{Kind: UnknownKey}
+}
+
+// splitScanKey splits a scan count or chunk key without its prefix into its
+// world, time, dimension and last part. Dimension IDs may themselves hold
+// slashes, whereas escaped worlds, times, block IDs and chunks can't.
+func splitScanKey(key string) (world string, t time.Time, dim, last string, ok bool) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) != 3 {
+		return "", time.Time{}, "", "", false
+	}
+
+	world, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return "", time.Time{}, "", "", false
+	}
+
+	t, err = time.Parse(ScanTimeLayout, parts[1])
+	if err != nil {
+		return "", time.Time{}, "", "", false
+	}
+
+	i := strings.LastIndex(parts[2], "/")
+	if i < 0 {
+		return "", time.Time{}, "", "", false
+	}
+
+	return world, t, parts[2][:i], parts[2][i+1:], true
+}
+
+// parseScanKey splits a scan count key without its prefix.
+func parseScanKey(key string) (Key, bool) {
+	world, t, dim, block, ok := splitScanKey(key)
+	if !ok {
+		return Key{}, false
+	}
+
+	return Key{Kind: ScanCountKey, World: world, Time: t, Dimension: dim, Block: block}, true
+}
+
+// parseScanChunkKey splits a scan chunk key without its prefix.
+func parseScanChunkKey(key string) (Key, bool) {
+	world, t, dim, chunk, ok := splitScanKey(key)
+	if !ok {
+		return Key{}, false
+	}
+
+	x, z, ok := parsePos(chunk, "")
+	if !ok {
+		return Key{}, false
+	}
+
+	return Key{Kind: ScanChunkKey, World: world, Time: t, Dimension: dim, Chunk: mc.ChunkPos{X: x, Z: z}}, true
+}
+
+// parseCacheKey splits a cached region or chunk key without its prefix,
+// working back from its end as dimension IDs may hold slashes.
+func parseCacheKey(key string) (Key, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) < 3 {
+		return Key{}, false
+	}
+
+	world, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return Key{}, false
+	}
+
+	k := Key{Kind: CachedRegionKey, World: world}
+	last := parts[len(parts)-1]
+	if x, z, ok := parsePos(last, "r."); ok {
+		k.Region = mc.RegionPos{X: x, Z: z}
+		k.Dimension = strings.Join(parts[1:len(parts)-1], "/")
+		return k, true
+	}
+
+	if len(parts) < 4 {
+		return Key{}, false
+	}
+
+	cx, cz, ok := parsePos(last, "")
+	if !ok {
+		return Key{}, false
+	}
+
+	rx, rz, ok := parsePos(parts[len(parts)-2], "r.")
+	if !ok {
+		return Key{}, false
+	}
+
+	k.Kind = CachedChunkKey
+	k.Region, k.Chunk = mc.RegionPos{X: rx, Z: rz}, mc.ChunkPos{X: cx, Z: cz}
+	k.Dimension = strings.Join(parts[1:len(parts)-2], "/")
+
+	return k, true
+}
+
+// parsePos parses exactly <prefix><x>.<z>.
+func parsePos(s, prefix string) (x, z int, ok bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return 0, 0, false
+	}
+
+	xs, zs, found := strings.Cut(s[len(prefix):], ".")
+	if !found {
+		return 0, 0, false
+	}
+
+	x, errX := strconv.Atoi(xs)
+	z, errZ := strconv.Atoi(zs)
+	return x, z, errX == nil && errZ == nil
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// ScanQuery selects block counts recorded by scans, each field left empty
+// matches every count.
+type ScanQuery struct {
+	World     string
+	Dimension string
+	Blocks    []string
+	// From and Until bound when the scans were made, From inclusive and
+	// Until exclusive.
+	From, Until time.Time
+}
+
+func (q ScanQuery) matches(k Key) bool {
+	if len(q.Dimension) > 0 && k.Dimension != q.Dimension {
+		return false
+	}
+
+	if (!q.From.IsZero() && k.Time.Before(q.From)) || (!q.Until.IsZero() && !k.Time.Before(q.Until)) {
+		return false
+	}
+
+	if len(q.Blocks) == 0 {
+		return true
+	}
+
+	for _, id := range q.Blocks {
+		if k.Block == id {
+			return true
+		}
+	}
+	return false
+}
+
+// BlockCount is the count of a single block within a dimension, as
+// recorded by a scan.
+type BlockCount struct {
+	World     string
+	Time      time.Time
+	Dimension string
+	Block     string
+	Count     uint64
+}
+
+// EachCount calls fn with every block count matching the query, ordered by
+// world and then oldest scan first. Only the scans within the query's time
+// range are read when the query is of a single world.
+func (db DB) EachCount(q ScanQuery, fn func(c BlockCount) error) error {
+	prefix := []byte(scanPrefix)
+	if len(q.World) > 0 {
+		prefix = []byte(scanPrefix + worldEscaper.Replace(q.World) + "/")
+	}
+
+	return db.conn.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: 100, Prefix: prefix})
+		defer it.Close()
+
+		it.Rewind()
+		if len(q.World) > 0 && !q.From.IsZero() {
+			it.Seek(append(prefix, q.From.UTC().Format(ScanTimeLayout)...))
+		}
+
+		for ; it.Valid(); it.Next() {
+			item := it.Item()
+			k := ParseKey(item.Key())
+			if k.Kind != ScanCountKey {
+				return fmt.Errorf("invalid scan key %s", item.Key())
+			}
+
+			// a single world's scans are in order, so none of the rest match
+			if len(q.World) > 0 && !q.Until.IsZero() && !k.Time.Before(q.Until) {
+				return nil
+			}
+
+			if !q.matches(k) {
+				continue
+			}
+
+			var count uint64
+			err := item.Value(func(v []byte) error {
+				if len(v) != 8 {
+					return fmt.Errorf("invalid count of %s", item.Key())
+				}
+				count = bytesToUint64(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := fn(BlockCount{World: k.World, Time: k.Time, Dimension: k.Dimension, Block: k.Block, Count: count}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Counts returns every block count matching the query, in the same order
+// as EachCount.
+func (db DB) Counts(q ScanQuery) ([]BlockCount, error) {
+	var counts []BlockCount
+	err := db.EachCount(q, func(c BlockCount) error {
+		counts = append(counts, c)
+		return nil
+	})
+	return counts, err
+}
+
+// TopCounts returns the n largest block counts matching the query, largest
+// first, or every count if n isn't positive. Counts from each scan matching
+// the query are ranked together, so a query should usually be of a single
+// scan, bounded by its time.
+func (db DB) TopCounts(q ScanQuery, n int) ([]BlockCount, error) {
+	counts, err := db.Counts(q)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Block < counts[j].Block
+	})
+
+	if n > 0 && len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts, nil
+}
+
+// LatestScan returns the time of the given world's most recent scan made
+// before the given time, or of its most recent scan if before is zero. ok
+// is false if there is no such scan.
+func (db DB) LatestScan(world string, before time.Time) (t time.Time, ok bool, err error) {
+	prefix := []byte(scanPrefix + worldEscaper.Replace(world) + "/")
+	seek := append(prefix, 0xFF)
+	if !before.IsZero() {
+		seek = append(prefix, before.UTC().Format(ScanTimeLayout)...)
+	}
+
+	err = db.conn.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Reverse: true, Prefix: prefix})
+		defer it.Close()
+
+		// seeking in reverse finds the last key at or before the one given,
+		// which a scan's keys at exactly before itself sort after
+		for it.Seek(seek); it.Valid(); it.Next() {
+			if k := ParseKey(it.Item().Key()); k.Kind == ScanCountKey {
+				t, ok = k.Time, true
+				return nil
+			}
+		}
+		return nil
+	})
+	return t, ok, err
+}
@@ -0,0 +1,119 @@
+package storage_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tauraamui/mcscan/internal/storage"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestParseKeyRoundTripsEachKind(t *testing.T) {
+	keys := []storage.Key{
+		{Kind: storage.SchemaKey},
+		{Kind: storage.ScanCountKey, World: "world", Time: time.Date(2023, 6, 1, 22, 0, 0, 5, time.UTC), Dimension: "custom:mining/deep", Block: "minecraft:stone"},
+		{Kind: storage.CachedRegionKey, World: "/saves/100% world", Dimension: "custom:mining/deep", Region: mc.RegionPos{X: -1, Z: 2}},
+		{Kind: storage.CachedChunkKey, World: "world", Dimension: mc.Overworld, Region: mc.RegionPos{X: -1, Z: 2}, Chunk: mc.ChunkPos{X: -32, Z: 64}},
+		{Kind: storage.ScanChunkKey, World: "/saves/world", Time: time.Date(2023, 6, 1, 22, 0, 0, 5, time.UTC), Dimension: "custom:mining/deep", Chunk: mc.ChunkPos{X: -32, Z: 64}},
+	}
+
+	for _, k := range keys {
+		if got := storage.ParseKey(k.Bytes()); !reflect.DeepEqual(got, k) {
+			t.Errorf("expected %s to parse as %+v, got %+v", k.Bytes(), k, got)
+		}
+	}
+
+	for _, key := range []string{"scan/world/yesterday/minecraft:overworld/minecraft:stone", "cache/world/r.1.x", "scan/%zz/20230601T220000.000000000Z/minecraft:overworld/minecraft:stone", "other"} {
+		if got := storage.ParseKey([]byte(key)); got.Kind != storage.UnknownKey {
+			t.Errorf("expected %s to be unknown, got %+v", key, got)
+		}
+	}
+}
+
+// putNightlyScans records a scan of each given world every night for days.
+func putNightlyScans(t *testing.T, db storage.DB, first time.Time, days int, worlds ...string) {
+	t.Helper()
+
+	for _, world := range worlds {
+		for i := 0; i < days; i++ {
+			err := db.PutScan(storage.Scan{World: world, Time: first.AddDate(0, 0, i), Counts: map[string]map[string]uint64{
+				mc.Overworld: {"minecraft:stone": 1000, "minecraft:diamond_ore": uint64(10 - i), "minecraft:hopper": uint64(i)},
+				mc.Nether:    {"minecraft:netherrack": 2000},
+			}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+}
+
+func TestDBQueriesCountsByWorldDimensionBlockAndTime(t *testing.T) {
+	db, err := storage.NewMemDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	first := time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC)
+	putNightlyScans(t, db, first, 5, "world", "world_2")
+
+	counts, err := db.Counts(storage.ScanQuery{
+		World:     "world",
+		Dimension: mc.Overworld,
+		Blocks:    []string{"minecraft:diamond_ore"},
+		From:      first.AddDate(0, 0, 1),
+		Until:     first.AddDate(0, 0, 3),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []storage.BlockCount{
+		{World: "world", Time: first.AddDate(0, 0, 1), Dimension: mc.Overworld, Block: "minecraft:diamond_ore", Count: 9},
+		{World: "world", Time: first.AddDate(0, 0, 2), Dimension: mc.Overworld, Block: "minecraft:diamond_ore", Count: 8},
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, counts)
+	}
+
+	every, err := db.Counts(storage.ScanQuery{Blocks: []string{"minecraft:hopper"}, From: first.AddDate(0, 0, 4)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(every) != 2 || every[0].World != "world" || every[1].World != "world_2" {
+		t.Errorf("expected the last hopper count of each world, got %+v", every)
+	}
+
+	latest, ok, err := db.LatestScan("world", time.Time{})
+	if err != nil || !ok || !latest.Equal(first.AddDate(0, 0, 4)) {
+		t.Fatalf("expected latest scan at %s, got %s %v %v", first.AddDate(0, 0, 4), latest, ok, err)
+	}
+
+	top, err := db.TopCounts(storage.ScanQuery{World: "world", From: latest, Until: latest.Add(time.Nanosecond)}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var blocks []string
+	for _, c := range top {
+		blocks = append(blocks, c.Block)
+	}
+	if !reflect.DeepEqual(blocks, []string{"minecraft:netherrack", "minecraft:stone", "minecraft:diamond_ore"}) {
+		t.Errorf("unexpected top counts %+v", top)
+	}
+
+	previous, ok, err := db.LatestScan("world", latest)
+	if err != nil || !ok || !previous.Equal(first.AddDate(0, 0, 3)) {
+		t.Errorf("expected the scan before the latest at %s, got %s %v %v", first.AddDate(0, 0, 3), previous, ok, err)
+	}
+
+	if _, ok, _ := db.LatestScan("world", first); ok {
+		t.Error("expected no scan before the first")
+	}
+
+	if _, ok, _ := db.LatestScan("unscanned", time.Time{}); ok {
+		t.Error("expected no latest scan of a world never scanned")
+	}
+}
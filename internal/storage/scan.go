@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+// ScanTimeLayout is how scan times are held within keys, in UTC, so that
+// a world's scans sort oldest first.
+const ScanTimeLayout = "20060102T150405.000000000Z"
+
+// Scan is the total of each block within a world at the time it was
+// scanned.
+type Scan struct {
+	// World identifies the world scanned, such as by its absolute path.
+	World string
+	Time  time.Time
+	// Counts are keyed by dimension ID, then block ID.
+	Counts map[string]map[string]uint64
+	// Chunks are the counts of each chunk, keyed by dimension ID. They're
+	// only recorded if given, and aren't read back by Scans.
+	Chunks map[string]map[mc.ChunkPos]map[string]uint64
+}
+
+// Dimensions returns the IDs of each dimension scanned, sorted.
+func (s Scan) Dimensions() []string {
+	dims := make([]string, 0, len(s.Counts))
+	for dim := range s.Counts {
+		dims = append(dims, dim)
+	}
+	sort.Strings(dims)
+	return dims
+}
+
+// Total returns the number of blocks counted within the given dimension.
+func (s Scan) Total(dim string) uint64 {
+	var total uint64
+	for _, count := range s.Counts[dim] {
+		total += count
+	}
+	return total
+}
+
+// PutScan records each of the scan's block counts, replacing those of any
+// scan of the same world at the same time.
+func (db DB) PutScan(s Scan) error {
+	if len(s.World) == 0 {
+		return errors.New("scan of no world")
+	}
+
+	// a scan may hold too many counts for a single transaction
+	wb := db.conn.NewWriteBatch()
+	defer wb.Cancel()
+
+	for dim, counts := range s.Counts {
+		for id, count := range counts {
+			key := Key{Kind: ScanCountKey, World: s.World, Time: s.Time, Dimension: dim, Block: id}
+			if err := wb.Set(key.Bytes(), uint64ToBytes(count)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for dim, chunks := range s.Chunks {
+		for pos, counts := range chunks {
+			key := Key{Kind: ScanChunkKey, World: s.World, Time: s.Time, Dimension: dim, Chunk: pos}
+			if err := wb.Set(key.Bytes(), appendCounts(nil, counts)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return wb.Flush()
+}
+
+// ScanChunks returns the counts of each chunk recorded by the given world's
+// scan at the given time, keyed by dimension ID. ok is false if the scan
+// recorded no chunks.
+func (db DB) ScanChunks(world string, t time.Time) (chunks map[string]map[mc.ChunkPos]map[string]uint64, ok bool, err error) {
+	prefix := []byte(scanChunkPrefix + worldEscaper.Replace(world) + "/" + t.UTC().Format(ScanTimeLayout) + "/")
+	chunks = map[string]map[mc.ChunkPos]map[string]uint64{}
+	err = db.conn.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: 100, Prefix: prefix})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := ParseKey(item.Key())
+			if key.Kind != ScanChunkKey {
+				return fmt.Errorf("invalid scan chunk key %s", item.Key())
+			}
+
+			err := item.Value(func(v []byte) error {
+				counts, err := readCounts(v)
+				if err != nil {
+					return fmt.Errorf("invalid counts of chunk %d,%d: %w", key.Chunk.X, key.Chunk.Z, err)
+				}
+
+				if chunks[key.Dimension] == nil {
+					chunks[key.Dimension] = map[mc.ChunkPos]map[string]uint64{}
+				}
+				chunks[key.Dimension][key.Chunk] = counts
+				ok = true
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return chunks, ok, nil
+}
+
+// Scans returns every scan with a block count matching the query, holding
+// only those counts, ordered by world and then oldest first.
+func (db DB) Scans(q ScanQuery) ([]Scan, error) {
+	var scans []Scan
+	err := db.EachCount(q, func(c BlockCount) error {
+		// counts are ordered by world then time, so each scan's are together
+		if len(scans) == 0 || scans[len(scans)-1].World != c.World || !scans[len(scans)-1].Time.Equal(c.Time) {
+			scans = append(scans, Scan{World: c.World, Time: c.Time, Counts: map[string]map[string]uint64{}})
+		}
+
+		s := scans[len(scans)-1]
+		if s.Counts[c.Dimension] == nil {
+			s.Counts[c.Dimension] = map[string]uint64{}
+		}
+		s.Counts[c.Dimension][c.Block] = c.Count
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return scans, nil
+}
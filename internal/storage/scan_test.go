@@ -0,0 +1,144 @@
+package storage_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tauraamui/mcscan/internal/storage"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestDBScansReadsBackEachScanInOrder(t *testing.T) {
+	db, err := storage.NewMemDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	first := time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC)
+	scans := []storage.Scan{
+		{World: "world", Time: first.Add(24 * time.Hour), Counts: map[string]map[string]uint64{
+			"minecraft:overworld": {"minecraft:stone": 90, "minecraft:diamond_ore": 2},
+		}},
+		{World: "world", Time: first, Counts: map[string]map[string]uint64{
+			"minecraft:overworld":  {"minecraft:stone": 100, "minecraft:diamond_ore": 4},
+			"custom:mining/deep":   {"minecraft:deepslate": 7},
+			"minecraft:the_nether": {"minecraft:netherrack": 50},
+		}},
+		{World: "world-2", Time: first, Counts: map[string]map[string]uint64{
+			"minecraft:overworld": {"minecraft:dirt": 1},
+		}},
+	}
+
+	for _, s := range scans {
+		if err := db.PutScan(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	got, err := db.Scans(storage.ScanQuery{World: "world"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || !got[0].Time.Equal(first) || !got[1].Time.Equal(first.Add(24*time.Hour)) {
+		t.Fatalf("unexpected scans %+v", got)
+	}
+
+	if !reflect.DeepEqual(got[0].Counts, scans[1].Counts) || !reflect.DeepEqual(got[1].Counts, scans[0].Counts) {
+		t.Errorf("unexpected counts %+v", got)
+	}
+
+	if got[0].Total("minecraft:overworld") != 104 {
+		t.Errorf("expected 104 overworld blocks, got %d", got[0].Total("minecraft:overworld"))
+	}
+
+	all, err := db.Scans(storage.ScanQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(all) != 3 {
+		t.Errorf("expected 3 scans of every world, got %d", len(all))
+	}
+
+	if err := db.PutScan(storage.Scan{Time: first}); err == nil {
+		t.Error("expected a scan of no world to be rejected")
+	}
+}
+
+func TestDBScansKeepWorldsSharingANameApart(t *testing.T) {
+	db, err := storage.NewMemDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	first := time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC)
+	worlds := []string{"/saves/world", "/backups/world", "/saves/world/copy", "/saves/100% world"}
+	for i, world := range worlds {
+		err := db.PutScan(storage.Scan{World: world, Time: first.Add(time.Duration(i) * time.Hour), Counts: map[string]map[string]uint64{
+			"minecraft:overworld": {"minecraft:stone": uint64(i + 1)},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	for i, world := range worlds {
+		got, err := db.Scans(storage.ScanQuery{World: world})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(got) != 1 || got[0].World != world || got[0].Counts["minecraft:overworld"]["minecraft:stone"] != uint64(i+1) {
+			t.Errorf("expected only the scan of %s, got %+v", world, got)
+		}
+
+		latest, ok, err := db.LatestScan(world, time.Time{})
+		if err != nil || !ok || !latest.Equal(first.Add(time.Duration(i)*time.Hour)) {
+			t.Errorf("expected the latest scan of %s to be its own, got %v, %t, %v", world, latest, ok, err)
+		}
+	}
+}
+
+func TestDBScanChunksReadsBackEachChunkOfAScan(t *testing.T) {
+	db, err := storage.NewMemDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	first := time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC)
+	chunks := map[string]map[mc.ChunkPos]map[string]uint64{
+		mc.Overworld:         {{X: -1, Z: 2}: {"minecraft:stone": 3}, {X: 0, Z: 0}: {"minecraft:dirt": 1}},
+		"custom:mining/deep": {{X: 5, Z: -5}: {"minecraft:deepslate": 7}},
+	}
+
+	scans := []storage.Scan{
+		{World: "/saves/world", Time: first, Counts: map[string]map[string]uint64{mc.Overworld: {"minecraft:stone": 1}}},
+		{World: "/saves/world", Time: first.Add(time.Hour), Counts: map[string]map[string]uint64{mc.Overworld: {"minecraft:stone": 3}}, Chunks: chunks},
+		{World: "/saves/world", Time: first.Add(2 * time.Hour), Chunks: map[string]map[mc.ChunkPos]map[string]uint64{
+			mc.Overworld: {{X: 0, Z: 0}: {"minecraft:stone": 9}},
+		}},
+	}
+	for _, s := range scans {
+		if err := db.PutScan(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	got, ok, err := db.ScanChunks("/saves/world", first.Add(time.Hour))
+	if err != nil || !ok {
+		t.Fatalf("expected recorded chunks, got %v %v", ok, err)
+	}
+
+	if !reflect.DeepEqual(got, chunks) {
+		t.Errorf("expected %v, got %v", chunks, got)
+	}
+
+	if _, ok, err := db.ScanChunks("/saves/world", first); ok || err != nil {
+		t.Errorf("expected a scan recorded without chunks to have none, got %v %v", ok, err)
+	}
+}
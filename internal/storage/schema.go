@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// SchemaVersion is the version of the layout of the keys and values this
+// package writes, databases of an older version are migrated when opened.
+const SchemaVersion = 2
+
+var (
+	// ErrSchemaTooNew is returned when opening a database written by a newer
+	// version of this package, which this version can't make sense of.
+	ErrSchemaTooNew = errors.New("database schema is newer than supported")
+	// ErrSchemaOutdated is returned when opening a database read only which
+	// needs to be migrated first.
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+)
+
+// schemaKey holds the database's schema version.
+var schemaKey = Key{Kind: SchemaKey}.Bytes()
+
+// migrations each upgrade a database from the version matching their index
+// to the next, each being recorded as it completes.
+var migrations = []func(db DB) error{
+	// databases from before the schema was versioned are laid out the same
+	// as version 1, whether empty or holding scans and cached counts
+	func(db DB) error { return nil },
+	// cached counts were keyed by the world's folder name, rather than the
+	// path scans are now keyed by, so are never read again
+	func(db DB) error { return db.conn.DropPrefix([]byte(cachePrefix)) },
+}
+
+// SchemaVersion returns the database's schema version, 0 being that of a
+// database from before the schema was versioned.
+func (db DB) SchemaVersion() (uint64, error) {
+	var version uint64
+	err := db.conn.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(schemaKey)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(v []byte) error {
+			if len(v) != 8 {
+				return fmt.Errorf("invalid schema version %x", v)
+			}
+			version = bytesToUint64(v)
+			return nil
+		})
+	})
+
+	return version, err
+}
+
+func (db DB) migrate() error {
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version > SchemaVersion:
+		return fmt.Errorf("%w, version %d rather than %d", ErrSchemaTooNew, version, SchemaVersion)
+	case version == SchemaVersion:
+		return nil
+	case db.conn.Opts().ReadOnly:
+		return fmt.Errorf("%w, version %d rather than %d, open it to be written to once to migrate it", ErrSchemaOutdated, version, SchemaVersion)
+	}
+
+	for ; version < SchemaVersion; version++ {
+		if err := migrations[version](db); err != nil {
+			return fmt.Errorf("unable to migrate database schema from version %d: %w", version, err)
+		}
+
+		err := db.conn.Update(func(txn *badger.Txn) error {
+			return txn.Set(schemaKey, uint64ToBytes(version+1))
+		})
+		if err != nil {
+			return fmt.Errorf("unable to record database schema version %d: %w", version+1, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package minecraft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tauraamui/mcscan/internal/vfs"
+)
+
+// AdvancementTimeLayout is how the game formats when each criterion was
+// met, e.g. 2023-06-09 00:14:15 +0100.
+const AdvancementTimeLayout = "2006-01-02 15:04:05 -0700"
+
+// multiPartCriteria lists every criterion of the advancements which
+// require many, as of 1.19.4. Advancement files only record the criteria
+// a player has met, so this is the only way of knowing what's missing.
+var multiPartCriteria = map[string][]string{
+	"minecraft:adventure/adventuring_time": namespacedAll(
+		"badlands", "bamboo_jungle", "beach", "birch_forest", "cold_ocean",
+		"dark_forest", "deep_cold_ocean", "deep_dark", "deep_frozen_ocean",
+		"deep_lukewarm_ocean", "deep_ocean", "desert", "dripstone_caves",
+		"flower_forest", "forest", "frozen_ocean", "frozen_peaks", "frozen_river",
+		"grove", "ice_spikes", "jagged_peaks", "jungle", "lukewarm_ocean",
+		"lush_caves", "mangrove_swamp", "meadow", "mushroom_fields", "ocean",
+		"old_growth_birch_forest", "old_growth_pine_taiga", "old_growth_spruce_taiga",
+		"plains", "river", "savanna", "savanna_plateau", "snowy_beach",
+		"snowy_plains", "snowy_slopes", "snowy_taiga", "sparse_jungle",
+		"stony_peaks", "stony_shore", "swamp", "taiga", "warm_ocean",
+		"windswept_forest", "windswept_gravelly_hills", "windswept_hills",
+		"windswept_savanna", "wooded_badlands",
+	),
+	"minecraft:adventure/kill_all_mobs": namespacedAll(
+		"blaze", "cave_spider", "creeper", "drowned", "elder_guardian",
+		"ender_dragon", "enderman", "endermite", "evoker", "ghast", "guardian",
+		"hoglin", "husk", "magma_cube", "phantom", "piglin", "piglin_brute",
+		"pillager", "ravager", "shulker", "silverfish", "skeleton", "slime",
+		"spider", "stray", "vex", "vindicator", "witch", "wither",
+		"wither_skeleton", "zoglin", "zombie", "zombie_villager", "zombified_piglin",
+	),
+	"minecraft:husbandry/bred_all_animals": namespacedAll(
+		"axolotl", "bee", "cat", "chicken", "cow", "donkey", "fox", "frog",
+		"goat", "hoglin", "horse", "llama", "mooshroom", "mule", "ocelot", "panda",
+		"pig", "rabbit", "sheep", "strider", "turtle", "wolf",
+	),
+	// cats are recorded by their variant
+	"minecraft:husbandry/complete_catalogue": namespacedAll(
+		"all_black", "black", "british_shorthair", "calico", "jellie", "persian",
+		"ragdoll", "red", "siamese", "tabby", "white",
+	),
+	"minecraft:nether/explore_nether": namespacedAll(
+		"basalt_deltas", "crimson_forest", "nether_wastes", "soul_sand_valley", "warped_forest",
+	),
+	// unlike biomes, foods are recorded without their namespace
+	"minecraft:husbandry/balanced_diet": {
+		"apple", "baked_potato", "beef", "beetroot", "beetroot_soup", "bread",
+		"carrot", "chicken", "chorus_fruit", "cod", "cooked_beef", "cooked_chicken",
+		"cooked_cod", "cooked_mutton", "cooked_porkchop", "cooked_rabbit",
+		"cooked_salmon", "cookie", "dried_kelp", "enchanted_golden_apple",
+		"glow_berries", "golden_apple", "golden_carrot", "honey_bottle",
+		"melon_slice", "mushroom_stew", "mutton", "poisonous_potato", "porkchop",
+		"potato", "pufferfish", "pumpkin_pie", "rabbit", "rabbit_stew",
+		"rotten_flesh", "salmon", "spider_eye", "suspicious_stew", "sweet_berries",
+		"tropical_fish",
+	},
+}
+
+func namespacedAll(ids ...string) []string {
+	for i, id := range ids {
+		ids[i] = namespaced(id)
+	}
+	return ids
+}
+
+type Advancement struct {
+	ID   string
+	Done bool
+	// Criteria holds when each criterion met so far was met.
+	Criteria map[string]time.Time
+}
+
+// IsRecipe reports whether the advancement only exists to unlock a recipe
+// within the recipe book, rather than being shown to players.
+func (a Advancement) IsRecipe() bool {
+	_, path, _ := strings.Cut(a.ID, ":")
+	return strings.HasPrefix(path, "recipes/")
+}
+
+// Missing returns the criteria yet to be met, sorted by name. It's nil
+// when done, or when the advancement's criteria aren't known.
+func (a Advancement) Missing() []string {
+	if a.Done {
+		return nil
+	}
+
+	var missing []string
+	for _, c := range multiPartCriteria[a.ID] {
+		if _, ok := a.Criteria[c]; !ok {
+			missing = append(missing, c)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// CompletedAt returns when the last criterion of a done advancement was
+// met, or the zero time if it's not done.
+func (a Advancement) CompletedAt() time.Time {
+	var latest time.Time
+	if !a.Done {
+		return latest
+	}
+
+	for _, t := range a.Criteria {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// CriterionProgress is a single criterion being met by a player.
+type CriterionProgress struct {
+	Advancement string
+	Criterion   string
+	Time        time.Time
+}
+
+// PlayerAdvancements are the advancements a player has made any progress
+// towards, sorted by ID.
+type PlayerAdvancements struct {
+	UUID         string
+	DataVersion  int
+	Advancements []Advancement
+}
+
+// Timeline returns every criterion met, oldest first, excluding recipe
+// unlocks unless includeRecipes is set.
+func (p PlayerAdvancements) Timeline(includeRecipes bool) []CriterionProgress {
+	timeline := []CriterionProgress{}
+	for _, a := range p.Advancements {
+		if a.IsRecipe() && !includeRecipes {
+			continue
+		}
+
+		for c, t := range a.Criteria {
+			timeline = append(timeline, CriterionProgress{Advancement: a.ID, Criterion: c, Time: t})
+		}
+	}
+
+	sort.Slice(timeline, func(i, j int) bool {
+		a, b := timeline[i], timeline[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Advancement != b.Advancement {
+			return a.Advancement < b.Advancement
+		}
+		return a.Criterion < b.Criterion
+	})
+
+	return timeline
+}
+
+type advancementTag struct {
+	Criteria map[string]string `json:"criteria"`
+	Done     bool              `json:"done"`
+}
+
+// Advancements returns the advancement progress of every player who has
+// joined the world, sorted by UUID.
+func (w World) Advancements() ([]PlayerAdvancements, error) {
+	found, err := vfs.Glob(w.fsys, filepath.Join(w.path, "advancements", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]PlayerAdvancements, 0, len(found))
+	for _, f := range found {
+		p, err := w.readAdvancements(f)
+		if err != nil {
+			return nil, err
+		}
+		players = append(players, p)
+	}
+
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].UUID < players[j].UUID
+	})
+
+	return players, nil
+}
+
+// PlayerAdvancements returns the advancement progress of the player with
+// the given UUID, the error wraps fs.ErrNotExist if there's none.
+func (w World) PlayerAdvancements(uuid string) (PlayerAdvancements, error) {
+	id, err := parseUUID(uuid)
+	if err != nil {
+		return PlayerAdvancements{}, err
+	}
+	return w.readAdvancements(filepath.Join(w.path, "advancements", id+".json"))
+}
+
+func (w World) readAdvancements(path string) (PlayerAdvancements, error) {
+	uuid := strings.TrimSuffix(filepath.Base(path), ".json")
+
+	data, err := fs.ReadFile(w.fsys, path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return PlayerAdvancements{}, fmt.Errorf("advancements for player %s not found: %w", uuid, err)
+		}
+		return PlayerAdvancements{}, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+
+	// advancements are keyed by ID alongside the data version
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return PlayerAdvancements{}, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+
+	p := PlayerAdvancements{UUID: uuid}
+	for id, msg := range raw {
+		if id == "DataVersion" {
+			if err := json.Unmarshal(msg, &p.DataVersion); err != nil {
+				return PlayerAdvancements{}, fmt.Errorf("unable to decode %s data version: %w", path, err)
+			}
+			continue
+		}
+
+		var at advancementTag
+		if err := json.Unmarshal(msg, &at); err != nil {
+			return PlayerAdvancements{}, fmt.Errorf("unable to decode %s advancement %s: %w", path, id, err)
+		}
+
+		a := Advancement{ID: id, Done: at.Done, Criteria: make(map[string]time.Time, len(at.Criteria))}
+		for c, ts := range at.Criteria {
+			t, err := time.Parse(AdvancementTimeLayout, ts)
+			if err != nil {
+				return PlayerAdvancements{}, fmt.Errorf("unable to parse %s advancement %s criterion %s: %w", path, id, c, err)
+			}
+			a.Criteria[c] = t
+		}
+		p.Advancements = append(p.Advancements, a)
+	}
+
+	sort.Slice(p.Advancements, func(i, j int) bool {
+		return p.Advancements[i].ID < p.Advancements[j].ID
+	})
+
+	return p, nil
+}
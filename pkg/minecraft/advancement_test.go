@@ -0,0 +1,94 @@
+package minecraft_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldAdvancementsReportsMissingCriteriaAndTimeline(t *testing.T) {
+	fsys := fstest.MapFS{
+		"world/advancements/00000000-0000-0000-0000-00000000000a.json": &fstest.MapFile{Data: []byte(`{
+			"minecraft:recipes/decorations/crafting_table": {"criteria": {"unlock_right_away": "2023-06-09 00:14:15 +0100"}, "done": true},
+			"minecraft:nether/explore_nether": {"criteria": {
+				"minecraft:nether_wastes": "2023-06-09 02:00:00 +0100",
+				"minecraft:warped_forest": "2023-06-09 01:00:00 +0100"
+			}, "done": false},
+			"minecraft:story/mine_stone": {"criteria": {"get_stone": "2023-06-09 00:20:00 +0100"}, "done": true},
+			"DataVersion": 3337
+		}`)},
+	}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	p, err := world.PlayerAdvancements("00000000-0000-0000-0000-00000000000A")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.DataVersion != 3337 || len(p.Advancements) != 3 {
+		t.Fatalf("unexpected advancements %+v", p)
+	}
+
+	explore := p.Advancements[0]
+	if explore.ID != "minecraft:nether/explore_nether" {
+		t.Fatalf("expected advancements sorted by ID, got %s first", explore.ID)
+	}
+
+	missing := explore.Missing()
+	want := []string{"minecraft:basalt_deltas", "minecraft:crimson_forest", "minecraft:soul_sand_valley"}
+	if len(missing) != len(want) {
+		t.Fatalf("expected missing %v, got %v", want, missing)
+	}
+	for i := range want {
+		if missing[i] != want[i] {
+			t.Errorf("expected missing %v, got %v", want, missing)
+			break
+		}
+	}
+
+	timeline := p.Timeline(false)
+	if len(timeline) != 3 {
+		t.Fatalf("expected 3 criteria excluding recipes, got %d", len(timeline))
+	}
+
+	if timeline[0].Criterion != "get_stone" || timeline[2].Criterion != "minecraft:nether_wastes" {
+		t.Errorf("expected timeline oldest first, got %+v", timeline)
+	}
+
+	if n := len(p.Timeline(true)); n != 4 {
+		t.Errorf("expected 4 criteria including recipes, got %d", n)
+	}
+}
+
+func TestAdvancementMissingListsEveryMultiPartCriterion(t *testing.T) {
+	tests := map[string]int{
+		"minecraft:adventure/adventuring_time":   50,
+		"minecraft:adventure/kill_all_mobs":      34,
+		"minecraft:husbandry/balanced_diet":      40,
+		"minecraft:husbandry/bred_all_animals":   22,
+		"minecraft:husbandry/complete_catalogue": 11,
+		"minecraft:nether/explore_nether":        5,
+	}
+
+	for id, expected := range tests {
+		if n := len((mc.Advancement{ID: id}).Missing()); n != expected {
+			t.Errorf("expected %s to be missing %d criteria, got %d", id, expected, n)
+		}
+	}
+
+	catalogue := mc.Advancement{ID: "minecraft:husbandry/complete_catalogue", Criteria: map[string]time.Time{}}
+	for _, cat := range []string{"black", "british_shorthair", "calico", "jellie", "persian", "ragdoll", "red", "siamese", "tabby", "white"} {
+		catalogue.Criteria["minecraft:"+cat] = time.Now()
+	}
+
+	if missing := catalogue.Missing(); len(missing) != 1 || missing[0] != "minecraft:all_black" {
+		t.Errorf("expected only minecraft:all_black to be missing, got %v", missing)
+	}
+}
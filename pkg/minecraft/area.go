@@ -0,0 +1,134 @@
+package minecraft
+
+// Area is a part of a world in block coordinates, either an axis aligned
+// box or a circle around a centre point, optionally bound to a Y range.
+// Every bound is inclusive.
+type Area struct {
+	minX, minZ, maxX, maxZ int
+
+	circular         bool
+	centreX, centreZ int
+	radius           int
+
+	yBounded   bool
+	minY, maxY int
+}
+
+// Box returns the area between the two given corners, including the
+// Y range between them.
+func Box(a, b BlockPos) Area {
+	return Area{
+		minX: min(a.X, b.X), maxX: max(a.X, b.X),
+		minZ: min(a.Z, b.Z), maxZ: max(a.Z, b.Z),
+		yBounded: true,
+		minY:     min(a.Y, b.Y), maxY: max(a.Y, b.Y),
+	}
+}
+
+// Around returns the circular area within radius blocks of the given
+// centre point, spanning the full height of the world.
+func Around(x, z, radius int) Area {
+	return Area{
+		minX: x - radius, maxX: x + radius,
+		minZ: z - radius, maxZ: z + radius,
+		circular: true,
+		centreX:  x, centreZ: z,
+		radius: radius,
+	}
+}
+
+// WithinY returns a copy of the area bound to the given Y range.
+func (a Area) WithinY(minY, maxY int) Area {
+	a.yBounded = true
+	a.minY, a.maxY = min(minY, maxY), max(minY, maxY)
+	return a
+}
+
+func (a Area) Contains(pos BlockPos) bool {
+	if a.yBounded && (pos.Y < a.minY || pos.Y > a.maxY) {
+		return false
+	}
+
+	if pos.X < a.minX || pos.X > a.maxX || pos.Z < a.minZ || pos.Z > a.maxZ {
+		return false
+	}
+
+	if !a.circular {
+		return true
+	}
+
+	return a.withinRadius(pos.X, pos.Z)
+}
+
+func (a Area) withinRadius(x, z int) bool {
+	dx, dz := x-a.centreX, z-a.centreZ
+	return dx*dx+dz*dz <= a.radius*a.radius
+}
+
+// intersectsXZ reports whether any column within the given bounds is
+// within the area.
+func (a Area) intersectsXZ(minX, minZ, maxX, maxZ int) bool {
+	if maxX < a.minX || minX > a.maxX || maxZ < a.minZ || minZ > a.maxZ {
+		return false
+	}
+
+	if !a.circular {
+		return true
+	}
+
+	// the point within the bounds closest to the centre
+	return a.withinRadius(clamp(a.centreX, minX, maxX), clamp(a.centreZ, minZ, maxZ))
+}
+
+// coversXZ reports whether every column within the given bounds is
+// within the area.
+func (a Area) coversXZ(minX, minZ, maxX, maxZ int) bool {
+	if minX < a.minX || maxX > a.maxX || minZ < a.minZ || maxZ > a.maxZ {
+		return false
+	}
+
+	if !a.circular {
+		return true
+	}
+
+	return a.withinRadius(minX, minZ) && a.withinRadius(minX, maxZ) &&
+		a.withinRadius(maxX, minZ) && a.withinRadius(maxX, maxZ)
+}
+
+func (a Area) intersectsY(minY, maxY int) bool {
+	return !a.yBounded || (maxY >= a.minY && minY <= a.maxY)
+}
+
+func (a Area) coversY(minY, maxY int) bool {
+	return !a.yBounded || (minY >= a.minY && maxY <= a.maxY)
+}
+
+func (a Area) intersectsRegion(pos RegionPos) bool {
+	return a.intersectsXZ(pos.X*512, pos.Z*512, pos.X*512+511, pos.Z*512+511)
+}
+
+func (a Area) intersectsChunk(pos ChunkPos) bool {
+	return a.intersectsXZ(pos.X*16, pos.Z*16, pos.X*16+15, pos.Z*16+15)
+}
+
+func (a Area) coversChunk(pos ChunkPos) bool {
+	return a.coversXZ(pos.X*16, pos.Z*16, pos.X*16+15, pos.Z*16+15)
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,115 @@
+package minecraft_test
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestAreaContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		area     mc.Area
+		pos      mc.BlockPos
+		expected bool
+	}{
+		{"inside box", mc.Box(mc.BlockPos{X: 10, Y: 0, Z: 10}, mc.BlockPos{X: -10, Y: 64, Z: -10}), mc.BlockPos{X: 0, Y: 32, Z: 0}, true},
+		{"box corner is inclusive", mc.Box(mc.BlockPos{X: 10, Y: 0, Z: 10}, mc.BlockPos{X: -10, Y: 64, Z: -10}), mc.BlockPos{X: -10, Y: 64, Z: 10}, true},
+		{"below box", mc.Box(mc.BlockPos{X: 10, Y: 0, Z: 10}, mc.BlockPos{X: -10, Y: 64, Z: -10}), mc.BlockPos{X: 0, Y: -1, Z: 0}, false},
+		{"beside box", mc.Box(mc.BlockPos{X: 10, Y: 0, Z: 10}, mc.BlockPos{X: -10, Y: 64, Z: -10}), mc.BlockPos{X: 11, Y: 32, Z: 0}, false},
+		{"within radius at any height", mc.Around(100, 100, 5), mc.BlockPos{X: 103, Y: -60, Z: 104}, true},
+		{"radius corner is outside", mc.Around(100, 100, 5), mc.BlockPos{X: 105, Y: 0, Z: 105}, false},
+		{"within radius but below Y range", mc.Around(100, 100, 5).WithinY(10, 20), mc.BlockPos{X: 100, Y: 9, Z: 100}, false},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.area.Contains(tt.pos); actual != tt.expected {
+			t.Errorf("%s: expected Contains(%+v) to be %t", tt.name, tt.pos, tt.expected)
+		}
+	}
+}
+
+func TestWorldScanWithinAreaOnlyCountsBlocksInside(t *testing.T) {
+	world := openTestdataWorld(t)
+	defer world.Close()
+
+	countWithin := func(a mc.Area) map[string]uint64 {
+		t.Helper()
+		counts, err := world.BlocksCount(mc.InDimensions(mc.Overworld), mc.WithinArea(a))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return counts[mc.Overworld]
+	}
+
+	sum := func(a, b map[string]uint64) map[string]uint64 {
+		total := map[string]uint64{}
+		for _, counts := range []map[string]uint64{a, b} {
+			for id, n := range counts {
+				total[id] += n
+			}
+		}
+		return total
+	}
+
+	// the whole of chunk -1,-6, which holds a spawner at -15,-14,-96
+	column := countWithin(mc.Box(mc.BlockPos{X: -16, Y: -64, Z: -96}, mc.BlockPos{X: -1, Y: 319, Z: -81}))
+	if column["minecraft:spawner"] != 1 {
+		t.Fatalf("expected 1 spawner within the chunk, got %d", column["minecraft:spawner"])
+	}
+
+	west := countWithin(mc.Box(mc.BlockPos{X: -16, Y: -64, Z: -96}, mc.BlockPos{X: -9, Y: 319, Z: -81}))
+	east := countWithin(mc.Box(mc.BlockPos{X: -8, Y: -64, Z: -96}, mc.BlockPos{X: -1, Y: 319, Z: -81}))
+	if !reflect.DeepEqual(sum(west, east), column) {
+		t.Error("expected the chunk's west and east halves to add up to the whole chunk")
+	}
+
+	if west["minecraft:spawner"] != 1 || east["minecraft:spawner"] != 0 {
+		t.Errorf("expected the spawner only within the west half, got %d and %d", west["minecraft:spawner"], east["minecraft:spawner"])
+	}
+
+	below := countWithin(mc.Box(mc.BlockPos{X: -16, Y: -64, Z: -96}, mc.BlockPos{X: -1, Y: -14, Z: -81}))
+	above := countWithin(mc.Box(mc.BlockPos{X: -16, Y: -13, Z: -96}, mc.BlockPos{X: -1, Y: 319, Z: -81}))
+	if !reflect.DeepEqual(sum(below, above), column) {
+		t.Error("expected the chunk below and above Y -14 to add up to the whole chunk")
+	}
+
+	if below["minecraft:spawner"] != 1 || above["minecraft:spawner"] != 0 {
+		t.Errorf("expected the spawner only below Y -13, got %d and %d", below["minecraft:spawner"], above["minecraft:spawner"])
+	}
+
+	found, err := world.FindBlocks([]string{"minecraft:spawner"}, mc.WithinArea(mc.Around(-15, -96, 2)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []mc.Block{{ID: "minecraft:spawner", Pos: mc.BlockPos{X: -15, Y: -14, Z: -96}, Chunk: mc.ChunkPos{X: -1, Z: -6}}}
+	if !reflect.DeepEqual(found[mc.Overworld], expected) {
+		t.Errorf("expected only the spawner at -15,-14,-96, found %+v", found[mc.Overworld])
+	}
+}
+
+func TestWorldScanWithinAreaSkipsRegionsAndChunksOutside(t *testing.T) {
+	// chunk 0,0 and region 1,0 can't be read, so scanning them would fail
+	fsys := buildMockFS()
+	fsys["config/minecraft/saves/test world/region/r.0.0.mca"] = &fstest.MapFile{Data: corruptRegion0()}
+	fsys["config/minecraft/saves/test world/region/r.1.0.mca"] = &fstest.MapFile{Data: region0[:1000]}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "config/minecraft/saves/test world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	// within chunk 1,0
+	area := mc.Box(mc.BlockPos{X: 16, Y: -64, Z: 0}, mc.BlockPos{X: 31, Y: 319, Z: 15})
+	if _, err := world.BlocksCount(mc.WithinArea(area)); err != nil {
+		t.Errorf("expected chunks and regions outside the area to be skipped, got %v", err)
+	}
+
+	if _, err := world.FindBlocks([]string{"minecraft:stone"}, mc.WithinArea(area)); err != nil {
+		t.Errorf("expected chunks and regions outside the area to be skipped, got %v", err)
+	}
+}
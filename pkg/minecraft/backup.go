@@ -0,0 +1,325 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tauraamui/mcscan/internal/filesystem"
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+// BackupIDLayout is how backups are named after when they were made, in
+// UTC, so that they sort oldest first.
+const BackupIDLayout = "20060102T150405.000Z"
+
+// backupsDir is where backups are kept, relative to the world.
+var backupsDir = filepath.Join(".mcscan", "backups")
+
+// createdManifest lists, within a backup, the files which didn't exist
+// before being written, one slash separated path per line.
+const createdManifest = ".created"
+
+// Backup is a copy of each file within the world as it was before being
+// written to.
+type Backup struct {
+	ID   string
+	Time time.Time
+	// Files are relative to the world, sorted by path.
+	Files []string
+	// Created are the files which didn't exist before being written, and
+	// so are removed by a restore. Sorted by path.
+	Created []string
+}
+
+// FileDiff is the changes a write would make to a file, or to a chunk
+// within a region file. Changes is nil if the file isn't NBT.
+type FileDiff struct {
+	File    string
+	Changes []nbtree.Change
+}
+
+type fileWrite struct {
+	// path is relative to the world.
+	path string
+	data []byte
+	// remove deletes the file rather than writing data to it.
+	remove bool
+}
+
+// writeFiles backs up each of the given files before writing them, unless
+// backups are disabled, or reports what would change on a dry run.
+func (w World) writeFiles(writes ...fileWrite) error {
+	if w.cfg.dryRun != nil {
+		for _, wr := range writes {
+			original, err := fs.ReadFile(w.fsys, filepath.Join(w.path, wr.path))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("unable to read %s: %w", wr.path, err)
+			}
+			if wr.remove && original == nil {
+				continue
+			}
+			w.cfg.dryRun(FileDiff{File: wr.path, Changes: diffNBTData(original, wr.data)})
+		}
+		return nil
+	}
+
+	if w.inUse && !w.cfg.ignoreLock {
+		return ErrWorldInUse
+	}
+
+	// worlds opened without WithSessionLock are only locked while written
+	if w.lock == nil && !w.cfg.ignoreLock {
+		lock, err := w.takeLock()
+		if err != nil {
+			return err
+		}
+		defer lock.Close()
+	}
+
+	if !w.cfg.noBackups {
+		paths := make([]string, 0, len(writes))
+		for _, wr := range writes {
+			paths = append(paths, wr.path)
+		}
+
+		if _, err := w.backup(paths...); err != nil {
+			return fmt.Errorf("unable to back up files before writing: %w", err)
+		}
+	}
+
+	for _, wr := range writes {
+		if wr.remove {
+			if err := w.fsys.Remove(filepath.Join(w.path, wr.path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("unable to remove %s: %w", wr.path, err)
+			}
+			continue
+		}
+
+		if err := filesystem.WriteFileAtomic(w.fsys, filepath.Join(w.path, wr.path), wr.data, 0o644); err != nil {
+			return fmt.Errorf("unable to write %s: %w", wr.path, err)
+		}
+	}
+
+	return nil
+}
+
+// diffNBTData returns the changes between two NBT files' data, or nil if
+// either isn't NBT.
+func diffNBTData(a, b []byte) []nbtree.Change {
+	roots := make([]nbtree.Field, 2)
+	for i, data := range [][]byte{a, b} {
+		if len(data) == 0 {
+			roots[i] = nbtree.Field{Node: nbtree.NewCompound(&nbtree.Compound{})}
+			continue
+		}
+
+		_, r, err := detectCompression(data)
+		if err != nil {
+			return nil
+		}
+
+		if roots[i], err = nbtree.Read(r); err != nil {
+			return nil
+		}
+	}
+
+	return nbtree.Diff(roots[0].Node, roots[1].Node)
+}
+
+// backup copies each of the given files which exist into a new backup,
+// recording those which don't so that restoring it removes them.
+func (w World) backup(paths ...string) (Backup, error) {
+	now := time.Now()
+	b := Backup{ID: now.UTC().Format(BackupIDLayout), Time: now}
+
+	// writes within the same millisecond get their own backup too
+	for i := 2; ; i++ {
+		_, err := w.fsys.Stat(filepath.Join(w.path, backupsDir, b.ID))
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return Backup{}, err
+		}
+		b.ID = fmt.Sprintf("%s-%d", now.UTC().Format(BackupIDLayout), i)
+	}
+
+	dir := filepath.Join(w.path, backupsDir, b.ID)
+	for _, path := range paths {
+		fi, err := w.fsys.Stat(filepath.Join(w.path, path))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				b.Created = append(b.Created, path)
+				continue
+			}
+			return Backup{}, err
+		}
+
+		data, err := fs.ReadFile(w.fsys, filepath.Join(w.path, path))
+		if err != nil {
+			return Backup{}, err
+		}
+
+		dst := filepath.Join(dir, path)
+		if err := w.fsys.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return Backup{}, err
+		}
+
+		// copies keep the original's permissions, so restoring them does too
+		if err := filesystem.WriteFileAtomic(w.fsys, dst, data, fi.Mode().Perm()); err != nil {
+			return Backup{}, err
+		}
+		b.Files = append(b.Files, path)
+	}
+
+	sort.Strings(b.Files)
+	sort.Strings(b.Created)
+
+	if len(b.Created) > 0 {
+		var manifest strings.Builder
+		for _, path := range b.Created {
+			manifest.WriteString(filepath.ToSlash(path) + "\n")
+		}
+
+		if err := w.fsys.MkdirAll(dir, 0o755); err != nil {
+			return Backup{}, err
+		}
+		if err := filesystem.WriteFileAtomic(w.fsys, filepath.Join(dir, createdManifest), []byte(manifest.String()), 0o644); err != nil {
+			return Backup{}, err
+		}
+	}
+
+	return b, nil
+}
+
+// Backups returns every backup made of the world's files, oldest first.
+func (w World) Backups() ([]Backup, error) {
+	entries, err := w.fsys.ReadDir(filepath.Join(w.path, backupsDir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	backups := make([]Backup, 0, len(entries))
+	for _, e := range entries {
+		// skip anything else which has found its way in
+		if _, err := parseBackupTime(e.Name()); !e.IsDir() || err != nil {
+			continue
+		}
+
+		b, err := w.readBackup(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, b)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID < backups[j].ID
+	})
+
+	return backups, nil
+}
+
+// parseBackupTime returns when the backup with the given ID was made.
+func parseBackupTime(id string) (time.Time, error) {
+	if filepath.Base(id) != id || len(id) < len(BackupIDLayout) {
+		return time.Time{}, fmt.Errorf("invalid backup %s", id)
+	}
+
+	t, err := time.Parse(BackupIDLayout, id[:len(BackupIDLayout)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid backup %s: %w", id, err)
+	}
+	return t, nil
+}
+
+func (w World) readBackup(id string) (Backup, error) {
+	t, err := parseBackupTime(id)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	dir := filepath.Join(w.path, backupsDir, id)
+	b := Backup{ID: id, Time: t}
+	err = fs.WalkDir(w.fsys, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && path != filepath.Join(dir, createdManifest) {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			b.Files = append(b.Files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Backup{}, fmt.Errorf("backup %s not found: %w", id, err)
+		}
+		return Backup{}, fmt.Errorf("unable to read backup %s: %w", id, err)
+	}
+
+	manifest, err := fs.ReadFile(w.fsys, filepath.Join(dir, createdManifest))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Backup{}, fmt.Errorf("unable to read backup %s: %w", id, err)
+	}
+
+	for _, line := range strings.Split(string(manifest), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		// the paths listed are removed on restore, so must be within the world
+		if !fs.ValidPath(line) || line == "." {
+			return Backup{}, fmt.Errorf("invalid path %q within backup %s", line, id)
+		}
+		b.Created = append(b.Created, filepath.FromSlash(line))
+	}
+
+	sort.Strings(b.Files)
+	sort.Strings(b.Created)
+	return b, nil
+}
+
+// Restore writes back every file within the given backup, and removes those
+// which were created after it. The files being replaced or removed are
+// themselves backed up first, so a restore may be undone.
+func (w World) Restore(id string) (Backup, error) {
+	b, err := w.readBackup(id)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	writes := make([]fileWrite, 0, len(b.Files)+len(b.Created))
+	for _, path := range b.Files {
+		data, err := fs.ReadFile(w.fsys, filepath.Join(w.path, backupsDir, id, path))
+		if err != nil {
+			return Backup{}, fmt.Errorf("unable to read backup %s: %w", id, err)
+		}
+		writes = append(writes, fileWrite{path: path, data: data})
+	}
+
+	for _, path := range b.Created {
+		writes = append(writes, fileWrite{path: path, remove: true})
+	}
+
+	return b, w.writeFiles(writes...)
+}
+
+// keepsOldFile reports whether the game keeps the previous version of the
+// given file alongside it, suffixed with _old, to fall back on should the
+// file be unreadable.
+func keepsOldFile(path string) bool {
+	return path == "level.dat" || (filepath.Dir(path) == "playerdata" && filepath.Ext(path) == ".dat")
+}
@@ -0,0 +1,119 @@
+package minecraft_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tauraamui/mcscan/internal/filesystem/filesystemtest"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldEditsAreBackedUpAndRestorable(t *testing.T) {
+	type data struct {
+		DayTime int64
+	}
+
+	original := gzipNBT(t, struct{ Data data }{data{DayTime: 6000}})
+	fsys := mockFS{MapFS: fstest.MapFS{"world/level.dat": &fstest.MapFile{Data: original, Mode: 0o600}}}
+
+	var diffs []mc.FileDiff
+	dryRun, err := mc.OpenWorld(fsys, "world", mc.WithDryRun(func(d mc.FileDiff) {
+		diffs = append(diffs, d)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := dryRun.EditLevel(mc.SetLevelValue("DayTime", "18000", "", false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(diffs) != 1 || len(diffs[0].Changes) != 1 || diffs[0].Changes[0].String() != "~ Data.DayTime: 6000L -> 18000L" {
+		t.Errorf("unexpected dry run diffs %+v", diffs)
+	}
+
+	if len(fsys.MapFS) != 1 || !bytes.Equal(fsys.MapFS["world/level.dat"].Data, original) {
+		t.Fatal("expected a dry run to leave the world untouched")
+	}
+	dryRun.Close()
+
+	world, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	if err := world.EditLevel(mc.SetLevelValue("DayTime", "18000", "", false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	backups, err := world.Backups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(backups) != 1 || len(backups[0].Files) != 1 || backups[0].Files[0] != "level.dat" {
+		t.Fatalf("unexpected backups %+v", backups)
+	}
+
+	if len(backups[0].Created) != 1 || backups[0].Created[0] != "level.dat_old" {
+		t.Fatalf("expected level.dat_old to be recorded as created, got %v", backups[0].Created)
+	}
+
+	if mode := fsys.MapFS["world/.mcscan/backups/"+backups[0].ID+"/level.dat"].Mode; mode != 0o600 {
+		t.Errorf("expected the backup to keep level.dat's mode 0600, got %v", mode)
+	}
+
+	if mode := fsys.MapFS["world/level.dat_old"].Mode; mode != 0o644 {
+		t.Errorf("expected new files to be created with mode 0644, got %v", mode)
+	}
+
+	if _, err := world.Restore(backups[0].ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(fsys.MapFS["world/level.dat"].Data, original) {
+		t.Error("expected level.dat to be restored")
+	}
+
+	if _, ok := fsys.MapFS["world/level.dat_old"]; ok {
+		t.Error("expected level.dat_old, created by the edit, to be removed")
+	}
+
+	// restoring backs up the files it replaces and removes too
+	backups, err = world.Backups()
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %d: %v", len(backups), err)
+	}
+
+	if files := backups[1].Files; len(files) != 2 || files[0] != "level.dat" || files[1] != "level.dat_old" {
+		t.Errorf("expected the restore to back up level.dat and level.dat_old, got %v", files)
+	}
+}
+
+func TestWorldFailedWriteKeepsOriginal(t *testing.T) {
+	type data struct {
+		DayTime int64
+	}
+
+	original := gzipNBT(t, struct{ Data data }{data{DayTime: 6000}})
+	files := fstest.MapFS{"world/level.dat": &fstest.MapFile{Data: original}}
+	fsys := &filesystemtest.FaultyFS{FS: mockFS{MapFS: files}}
+	fsys.FailWriteAfter(".level.dat.tmp*", 10)
+
+	world, err := mc.OpenWorld(fsys, "world", mc.WithoutBackups())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	if err := world.EditLevel(mc.SetLevelValue("DayTime", "18000", "", false)); !errors.Is(err, filesystemtest.ErrInjected) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+
+	if !bytes.Equal(files["world/level.dat"].Data, original) {
+		t.Error("expected level.dat to survive the failed write")
+	}
+}
@@ -0,0 +1,177 @@
+package minecraft
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Item is a stack of items, along with any items held within it such as
+// the contents of a shulker box or bundle.
+type Item struct {
+	ID       string
+	Count    int
+	Slot     int
+	Contents []Item
+}
+
+// Container is a block entity which holds items, such as a chest, barrel,
+// shulker box or hopper.
+type Container struct {
+	ID         string
+	Pos        BlockPos
+	Chunk      ChunkPos
+	CustomName string
+	Items      []Item
+}
+
+// ItemCounts returns the total of each item ID held within the container,
+// including the contents of any held shulker boxes and bundles.
+func (c Container) ItemCounts() map[string]uint64 {
+	counts := map[string]uint64{}
+	countItems(c.Items, counts)
+	return counts
+}
+
+// Holds reports whether the container, or any item within it, holds an
+// item with the given ID.
+func (c Container) Holds(id string) bool {
+	return holdsItem(c.Items, id)
+}
+
+// CountItems returns the total of each item ID held across all of the
+// given containers.
+func CountItems(containers []Container) map[string]uint64 {
+	counts := map[string]uint64{}
+	for _, c := range containers {
+		countItems(c.Items, counts)
+	}
+	return counts
+}
+
+func countItems(items []Item, counts map[string]uint64) {
+	for _, item := range items {
+		counts[item.ID] += uint64(item.Count)
+		countItems(item.Contents, counts)
+	}
+}
+
+func holdsItem(items []Item, id string) bool {
+	for _, item := range items {
+		if item.ID == id || holdsItem(item.Contents, id) {
+			return true
+		}
+	}
+	return false
+}
+
+type blockEntityTag struct {
+	ID         string `nbt:"id"`
+	X          int32  `nbt:"x"`
+	Y          int32  `nbt:"y"`
+	Z          int32  `nbt:"z"`
+	CustomName string
+	Items      []itemTag
+}
+
+type itemTag struct {
+	ID    string `nbt:"id"`
+	Count int32
+	Slot  int32
+	Tag   struct {
+		// shulker boxes keep their contents within their block entity tag
+		// whereas bundles hold them directly
+		BlockEntityTag struct {
+			Items []itemTag
+		}
+		Items []itemTag
+	} `nbt:"tag"`
+}
+
+func (t itemTag) item() Item {
+	item := Item{ID: t.ID, Count: int(t.Count), Slot: int(t.Slot)}
+	item.Contents = append(toItems(t.Tag.BlockEntityTag.Items), toItems(t.Tag.Items)...)
+	return item
+}
+
+func toItems(tags []itemTag) []Item {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	items := make([]Item, len(tags))
+	for i, t := range tags {
+		items[i] = t.item()
+	}
+	return items
+}
+
+// appendContainers appends every block entity within the chunk, and area
+// if given, which holds at least one item.
+func (c *decodedChunk) appendContainers(containers []Container, area *Area) ([]Container, error) {
+	for _, raw := range c.saved.BlockEntities {
+		var be blockEntityTag
+		if err := raw.Unmarshal(&be); err != nil {
+			return nil, fmt.Errorf("unable to decode block entity: %w", err)
+		}
+
+		if len(be.Items) == 0 {
+			continue
+		}
+
+		pos := BlockPos{X: int(be.X), Y: int(be.Y), Z: int(be.Z)}
+		if area != nil && !area.Contains(pos) {
+			continue
+		}
+
+		containers = append(containers, Container{
+			ID:         be.ID,
+			Pos:        pos,
+			Chunk:      c.pos,
+			CustomName: be.CustomName,
+			Items:      toItems(be.Items),
+		})
+	}
+
+	return containers, nil
+}
+
+// Containers returns every block entity holding items, keyed by the ID of
+// the dimension the containers were found in.
+func (w World) Containers(opts ...ScanOption) (map[string][]Container, error) {
+	cfg := resolveScanConfig(opts)
+	dims, err := w.selectDimensions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string][]Container{}
+	for _, dim := range dims {
+		workerContainers := []*[]Container{}
+		err := w.scanChunks(cfg, dim.regions, func() chunkWorker {
+			containers := []Container{}
+			workerContainers = append(workerContainers, &containers)
+			return terrainWorker(func(c *decodedChunk) (err error) {
+				containers, err = c.appendContainers(containers, cfg.area)
+				return err
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		containers := []Container{}
+		for _, wc := range workerContainers {
+			containers = append(containers, *wc...)
+		}
+
+		sort.Slice(containers, func(i, j int) bool {
+			return lessBlock(
+				Block{ID: containers[i].ID, Pos: containers[i].Pos},
+				Block{ID: containers[j].ID, Pos: containers[j].Pos},
+			)
+		})
+		found[dim.ID] = containers
+	}
+
+	return found, nil
+}
@@ -0,0 +1,73 @@
+package minecraft_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldContainersIncludesNestedItems(t *testing.T) {
+	type item struct {
+		ID    string `nbt:"id"`
+		Count byte
+		Slot  byte
+		Tag   map[string]any `nbt:"tag,omitempty"`
+	}
+
+	type blockEntity struct {
+		ID    string `nbt:"id"`
+		X     int32  `nbt:"x"`
+		Y     int32  `nbt:"y"`
+		Z     int32  `nbt:"z"`
+		Items []item
+	}
+
+	chest := blockEntity{
+		ID: "minecraft:chest", X: 20, Y: 64, Z: 35,
+		Items: []item{
+			{ID: "minecraft:diamond", Count: 3, Slot: 0},
+			{ID: "minecraft:shulker_box", Count: 1, Slot: 1, Tag: map[string]any{
+				"BlockEntityTag": map[string]any{
+					"Items": []item{{ID: "minecraft:diamond", Count: 64, Slot: 0}},
+				},
+			}},
+		},
+	}
+
+	fsys := fstest.MapFS{
+		"world/region/r.0.0.mca": &fstest.MapFile{Data: buildRegion(t, testChunk{
+			XPos: 1, ZPos: 2, Status: "full",
+			BlockEntities: []blockEntity{chest},
+		})},
+	}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	found, err := world.Containers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	containers := found[mc.Overworld]
+	if len(containers) != 1 {
+		t.Fatalf("expected 1 container, got %d", len(containers))
+	}
+
+	if pos := containers[0].Pos; pos != (mc.BlockPos{X: 20, Y: 64, Z: 35}) {
+		t.Errorf("unexpected container position %+v", pos)
+	}
+
+	counts := mc.CountItems(containers)
+	if counts["minecraft:diamond"] != 67 {
+		t.Errorf("expected 67 diamonds including the shulker box's contents, got %d", counts["minecraft:diamond"])
+	}
+
+	if counts["minecraft:shulker_box"] != 1 {
+		t.Errorf("expected 1 shulker box, got %d", counts["minecraft:shulker_box"])
+	}
+}
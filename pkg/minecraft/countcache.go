@@ -0,0 +1,305 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Tnze/go-mc/level/block"
+)
+
+// RegionCounts is the total of each block within a region, as of when its
+// file was last modified.
+type RegionCounts struct {
+	ModTime time.Time
+	Size    int64
+	Counts  map[string]uint64
+}
+
+// ChunkCounts is the total of each block within a chunk, as of the time its
+// region file records the chunk as last being saved.
+type ChunkCounts struct {
+	Timestamp int32
+	Counts    map[string]uint64
+}
+
+// BlocksCountCache holds the block counts of each region and chunk from a
+// previous scan, keyed by dimension ID.
+type BlocksCountCache interface {
+	// Region returns the cached counts of the region at pos, ok is false
+	// if the region has never been cached.
+	Region(dim string, pos RegionPos) (counts RegionCounts, ok bool, err error)
+	// Chunks returns the cached counts of each chunk within the region at pos.
+	Chunks(dim string, pos RegionPos) (map[ChunkPos]ChunkCounts, error)
+	// PutRegion replaces the cached counts of the region at pos, along with
+	// those of each of its chunks.
+	PutRegion(dim string, pos RegionPos, counts RegionCounts, chunks map[ChunkPos]ChunkCounts) error
+}
+
+// WithBlocksCountCache makes BlocksCount and ChunkBlocksCount only decode
+// the chunks saved since they were cached, the counts of which are then
+// cached in turn. BlocksCount doesn't read regions whose file is unmodified
+// at all. It can't be used along with WithinArea, as only whole chunks are
+// cached.
+//
+// Chunks are timestamped to the second, so a chunk saved again within the
+// same second it was cached isn't decoded until it's next saved.
+func WithBlocksCountCache(cache BlocksCountCache) ScanOption {
+	return func(cfg *scanConfig) {
+		cfg.countCache = cache
+	}
+}
+
+// staleRegion is a region whose file has been modified since it was cached.
+type staleRegion struct {
+	ref     region
+	modTime time.Time
+	size    int64
+	// timestamps are of each chunk within the region.
+	timestamps map[ChunkPos]int32
+	cached     map[ChunkPos]ChunkCounts
+	decode     map[ChunkPos]bool
+}
+
+// cachedRegionsBlocksCount counts the blocks within the dimension, taking
+// the counts of every region and chunk which is unchanged from the cache.
+// The counts of each chunk are also added to chunks, unless it's nil.
+func (w World) cachedRegionsBlocksCount(cfg scanConfig, dim Dimension, chunks map[ChunkPos]map[string]uint64) (map[string]uint64, error) {
+	count := map[string]uint64{}
+	stale := map[RegionPos]*staleRegion{}
+	var staleRefs []region
+	for _, rref := range dim.regions {
+		fi, err := w.fsys.Stat(rref.path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to stat region %s: %w", rref.path, err)
+		}
+
+		cached, ok, err := cfg.countCache.Region(dim.ID, rref.pos)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read cached counts of region %s: %w", rref.path, err)
+		}
+
+		if ok && cached.ModTime.Equal(fi.ModTime()) && cached.Size == fi.Size() {
+			addCounts(count, cached.Counts)
+			if chunks == nil {
+				continue
+			}
+
+			cachedChunks, err := cfg.countCache.Chunks(dim.ID, rref.pos)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read cached counts of region %s: %w", rref.path, err)
+			}
+			for pos, c := range cachedChunks {
+				chunks[pos] = c.Counts
+			}
+			continue
+		}
+
+		s := staleRegion{ref: rref, modTime: fi.ModTime(), size: fi.Size(), decode: map[ChunkPos]bool{}}
+		if s.timestamps, err = w.readChunkTimestamps(rref.path); err != nil {
+			return nil, err
+		}
+
+		// without the region the cached chunks can't be trusted to be whole
+		if ok {
+			if s.cached, err = cfg.countCache.Chunks(dim.ID, rref.pos); err != nil {
+				return nil, fmt.Errorf("unable to read cached counts of region %s: %w", rref.path, err)
+			}
+		}
+
+		for pos, ts := range s.timestamps {
+			if c, ok := s.cached[pos]; !ok || c.Timestamp != ts {
+				s.decode[pos] = true
+			}
+		}
+
+		stale[rref.pos] = &s
+		staleRefs = append(staleRefs, rref)
+	}
+
+	cfg.onlyChunks = func(pos RegionPos, chunk ChunkPos) bool {
+		return stale[pos].decode[chunk]
+	}
+
+	// reports are serialised by the scan, so damaged needs no lock
+	damaged := map[string]bool{}
+	if report := cfg.onCorrupt; report != nil {
+		cfg.onCorrupt = func(err *ChunkCorruptError) error {
+			damaged[err.Region] = true
+			return report(err)
+		}
+	}
+
+	decoded, err := w.chunksBlocksCount(cfg, staleRefs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rref := range staleRefs {
+		s := stale[rref.pos]
+		region := RegionCounts{ModTime: s.modTime, Size: s.size, Counts: map[string]uint64{}}
+		if damaged[rref.path] {
+			// no file is this size, so the region is left stale and what was
+			// skipped is retried next time
+			region.Size = -1
+		}
+
+		regionChunks := make(map[ChunkPos]ChunkCounts, len(s.timestamps))
+		for pos, ts := range s.timestamps {
+			c, ok := s.cached[pos]
+			if s.decode[pos] {
+				// chunks skipped as corrupt are left out, to be retried
+				counts, decodedOK := decoded[pos]
+				c, ok = ChunkCounts{Timestamp: ts, Counts: counts}, decodedOK
+			}
+
+			if !ok {
+				continue
+			}
+
+			regionChunks[pos] = c
+			addCounts(region.Counts, c.Counts)
+			if chunks != nil {
+				chunks[pos] = c.Counts
+			}
+		}
+
+		if err := cfg.countCache.PutRegion(dim.ID, rref.pos, region, regionChunks); err != nil {
+			return nil, fmt.Errorf("unable to cache counts of region %s: %w", rref.path, err)
+		}
+		addCounts(count, region.Counts)
+	}
+
+	return count, nil
+}
+
+// chunksBlocksCount counts the blocks of each chunk within the given
+// regions, and the configured area, by block ID.
+func (w World) chunksBlocksCount(cfg scanConfig, regions []region) (map[ChunkPos]map[string]uint64, error) {
+	workerChunks := []*map[ChunkPos]map[string]uint64{}
+	err := w.scanChunks(cfg, regions, func() chunkWorker {
+		counter := chunkCounter{counts: make([]uint64, len(block.StateList))}
+		chunks := map[ChunkPos]map[string]uint64{}
+		workerChunks = append(workerChunks, &chunks)
+		return terrainWorker(func(c *decodedChunk) error {
+			counts, err := counter.count(c, cfg.area)
+			if err != nil {
+				return err
+			}
+			chunks[c.pos] = counts
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counted := map[ChunkPos]map[string]uint64{}
+	for _, wc := range workerChunks {
+		for pos, counts := range *wc {
+			counted[pos] = counts
+		}
+	}
+
+	return counted, nil
+}
+
+// readChunkTimestamps returns when each chunk within the region at path
+// was last saved, as held in the region's header.
+func (w World) readChunkTimestamps(path string) (map[ChunkPos]int32, error) {
+	fd, err := w.regionFDs.open(w.fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open region %s: %w", path, err)
+	}
+	defer w.regionFDs.close(fd)
+
+	pos, err := parseRegionPos(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// the header is a table of each chunk's location, then of its timestamp,
+	// but the game creates region files before saving any chunks into them.
+	// A header cut short is left for the scan to report as corrupt.
+	header := make([]byte, 2*4096)
+	if _, err := io.ReadFull(fd, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return map[ChunkPos]int32{}, nil
+		}
+		return nil, fmt.Errorf("unable to read header of region %s: %w", path, err)
+	}
+
+	timestamps := map[ChunkPos]int32{}
+	for i := 0; i < 32*32; i++ {
+		if be32(header[i*4:]) == 0 {
+			continue
+		}
+
+		chunk := ChunkPos{X: pos.X*32 + i%32, Z: pos.Z*32 + i/32}
+		timestamps[chunk] = int32(be32(header[4096+i*4:]))
+	}
+
+	return timestamps, nil
+}
+
+func be32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+func addCounts(dst, src map[string]uint64) {
+	for id, n := range src {
+		dst[id] += n
+	}
+}
+
+// chunkCounter counts the blocks of a single chunk at a time by block ID,
+// within an area if given, reusing counts, which is indexed by state ID,
+// between chunks.
+type chunkCounter struct {
+	counts  []uint64
+	touched []block.StateID
+}
+
+func (cc *chunkCounter) count(c *decodedChunk, area *Area) (map[string]uint64, error) {
+	sections, err := c.sections()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(sections); i++ {
+		sec := sections[i]
+		if sec.BlockCount == 0 {
+			continue
+		}
+
+		sectionY := i + c.minSectionY()
+		within := c.sectionWithin(area, sectionY)
+		if within == outsideArea {
+			continue
+		}
+
+		for j := 0; j < 16*16*16; j++ {
+			if within == overlapsArea && !area.Contains(sectionBlockPos(c.pos, sectionY, j)) {
+				continue
+			}
+
+			state := sec.GetBlock(j)
+			if cc.counts[state] == 0 {
+				cc.touched = append(cc.touched, block.StateID(state))
+			}
+			cc.counts[state]++
+		}
+	}
+
+	counts := make(map[string]uint64, len(cc.touched))
+	for _, state := range cc.touched {
+		if !block.IsAir(state) {
+			counts[block.StateList[state].ID()] += cc.counts[state]
+		}
+		cc.counts[state] = 0
+	}
+	cc.touched = cc.touched[:0]
+
+	return counts, nil
+}
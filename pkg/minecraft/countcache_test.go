@@ -0,0 +1,177 @@
+package minecraft_test
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+type regionKey struct {
+	dim string
+	pos mc.RegionPos
+}
+
+// memCountCache is a BlocksCountCache held in memory.
+type memCountCache struct {
+	regions map[regionKey]mc.RegionCounts
+	chunks  map[regionKey]map[mc.ChunkPos]mc.ChunkCounts
+}
+
+func (m memCountCache) Region(dim string, pos mc.RegionPos) (mc.RegionCounts, bool, error) {
+	r, ok := m.regions[regionKey{dim, pos}]
+	return r, ok, nil
+}
+
+func (m memCountCache) Chunks(dim string, pos mc.RegionPos) (map[mc.ChunkPos]mc.ChunkCounts, error) {
+	return m.chunks[regionKey{dim, pos}], nil
+}
+
+func (m memCountCache) PutRegion(dim string, pos mc.RegionPos, counts mc.RegionCounts, chunks map[mc.ChunkPos]mc.ChunkCounts) error {
+	m.regions[regionKey{dim, pos}] = counts
+	m.chunks[regionKey{dim, pos}] = chunks
+	return nil
+}
+
+// solidChunk returns a chunk whose only section is entirely the given block.
+func solidChunk(x, z int32, id string) testChunk {
+	type blockState struct {
+		Name string
+	}
+
+	type section struct {
+		Y           int8
+		BlockStates struct {
+			Palette []blockState `nbt:"palette"`
+		} `nbt:"block_states"`
+		Biomes struct {
+			Palette []string `nbt:"palette"`
+		} `nbt:"biomes"`
+	}
+
+	sec := section{}
+	sec.BlockStates.Palette = []blockState{{Name: id}}
+	sec.Biomes.Palette = []string{"minecraft:plains"}
+
+	return testChunk{XPos: x, ZPos: z, Status: "minecraft:full", Sections: []section{sec}}
+}
+
+func TestWorldBlocksCountOnlyDecodesChunksChangedSinceCached(t *testing.T) {
+	modTime := time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC)
+	region0 := buildRegion(t, solidChunk(0, 0, "minecraft:stone"), solidChunk(1, 0, "minecraft:dirt"), solidChunk(2, 0, "minecraft:granite"))
+	fsys := mockFS{MapFS: fstest.MapFS{
+		"world/region/r.0.0.mca": &fstest.MapFile{Data: region0, ModTime: modTime},
+		"world/region/r.1.0.mca": &fstest.MapFile{Data: buildRegion(t, solidChunk(32, 0, "minecraft:sand")), ModTime: modTime},
+	}}
+
+	world, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	cache := memCountCache{regions: map[regionKey]mc.RegionCounts{}, chunks: map[regionKey]map[mc.ChunkPos]mc.ChunkCounts{}}
+	counts, err := world.BlocksCount(mc.WithBlocksCountCache(cache))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]uint64{"minecraft:stone": 4096, "minecraft:dirt": 4096, "minecraft:granite": 4096, "minecraft:sand": 4096}
+	if !reflect.DeepEqual(counts[mc.Overworld], expected) {
+		t.Fatalf("expected %v, got %v", expected, counts[mc.Overworld])
+	}
+
+	region0Key := regionKey{mc.Overworld, mc.RegionPos{X: 0, Z: 0}}
+	region1Key := regionKey{mc.Overworld, mc.RegionPos{X: 1, Z: 0}}
+	if len(cache.chunks[region0Key]) != 3 || cache.regions[region0Key].Size != int64(len(region0)) {
+		t.Fatalf("expected region 0,0 and its chunks to be cached, got %+v", cache.regions[region0Key])
+	}
+
+	// an unmodified region is taken from the cache as is
+	region1 := cache.regions[region1Key]
+	region1.Counts = map[string]uint64{"test:unmodified_region": 1}
+	cache.regions[region1Key] = region1
+
+	// whereas only the chunks saved since are decoded from a modified one
+	region0Counts := cache.regions[region0Key]
+	region0Counts.ModTime = modTime.Add(-time.Hour)
+	cache.regions[region0Key] = region0Counts
+
+	chunks := cache.chunks[region0Key]
+	chunks[mc.ChunkPos{X: 0, Z: 0}] = mc.ChunkCounts{
+		Timestamp: chunks[mc.ChunkPos{X: 0, Z: 0}].Timestamp,
+		Counts:    map[string]uint64{"test:unchanged_chunk": 2},
+	}
+	chunks[mc.ChunkPos{X: 1, Z: 0}] = mc.ChunkCounts{
+		Timestamp: chunks[mc.ChunkPos{X: 1, Z: 0}].Timestamp - 1,
+		Counts:    map[string]uint64{"test:changed_chunk": 3},
+	}
+	chunks[mc.ChunkPos{X: 31, Z: 31}] = mc.ChunkCounts{Counts: map[string]uint64{"test:removed_chunk": 4}}
+
+	counts, err = world.BlocksCount(mc.WithBlocksCountCache(cache))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = map[string]uint64{"test:unchanged_chunk": 2, "minecraft:dirt": 4096, "minecraft:granite": 4096, "test:unmodified_region": 1}
+	if !reflect.DeepEqual(counts[mc.Overworld], expected) {
+		t.Errorf("expected %v, got %v", expected, counts[mc.Overworld])
+	}
+
+	if !cache.regions[region0Key].ModTime.Equal(modTime) || len(cache.chunks[region0Key]) != 3 {
+		t.Errorf("expected region 0,0 to be cached again without the removed chunk, got %+v", cache.chunks[region0Key])
+	}
+
+	if _, err := world.BlocksCount(mc.WithBlocksCountCache(cache), mc.WithinArea(mc.Around(0, 0, 16))); err == nil {
+		t.Error("expected a cached count within an area to fail")
+	}
+}
+
+func TestWorldChunkBlocksCountTakesUnchangedChunksFromCache(t *testing.T) {
+	modTime := time.Date(2023, 6, 1, 22, 0, 0, 0, time.UTC)
+	fsys := mockFS{MapFS: fstest.MapFS{
+		"world/region/r.0.0.mca": &fstest.MapFile{Data: buildRegion(t, solidChunk(0, 0, "minecraft:stone"), solidChunk(1, 0, "minecraft:dirt")), ModTime: modTime},
+		"world/region/r.1.0.mca": &fstest.MapFile{Data: buildRegion(t, solidChunk(32, 0, "minecraft:sand")), ModTime: modTime},
+	}}
+
+	world, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	cache := memCountCache{regions: map[regionKey]mc.RegionCounts{}, chunks: map[regionKey]map[mc.ChunkPos]mc.ChunkCounts{}}
+	uncached, err := world.ChunkBlocksCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cached, err := world.ChunkBlocksCount(mc.WithBlocksCountCache(cache))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cached, uncached) {
+		t.Fatalf("expected %v, got %v", uncached, cached)
+	}
+
+	// the chunks of an unmodified region are taken from the cache as is
+	region1Key := regionKey{mc.Overworld, mc.RegionPos{X: 1, Z: 0}}
+	chunk := mc.ChunkPos{X: 32, Z: 0}
+	cache.chunks[region1Key][chunk] = mc.ChunkCounts{Counts: map[string]uint64{"test:unmodified_region": 1}}
+
+	cached, err = world.ChunkBlocksCount(mc.WithBlocksCountCache(cache))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if counts := cached[mc.Overworld][chunk]; !reflect.DeepEqual(counts, map[string]uint64{"test:unmodified_region": 1}) {
+		t.Errorf("expected chunk 32,0 to be taken from the cache, got %v", counts)
+	}
+
+	if len(cached[mc.Overworld]) != 3 {
+		t.Errorf("expected 3 chunks, got %d", len(cached[mc.Overworld]))
+	}
+}
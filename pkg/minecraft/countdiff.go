@@ -0,0 +1,21 @@
+package minecraft
+
+// DiffCounts returns how much each block's count changed from one count to
+// another, such as between two of BlocksCount's dimensions or two chunks.
+// Blocks whose count didn't change are left out.
+func DiffCounts(from, to map[string]uint64) map[string]int64 {
+	deltas := map[string]int64{}
+	for id, n := range to {
+		if d := int64(n) - int64(from[id]); d != 0 {
+			deltas[id] = d
+		}
+	}
+
+	for id, n := range from {
+		if _, ok := to[id]; !ok && n != 0 {
+			deltas[id] = -int64(n)
+		}
+	}
+
+	return deltas
+}
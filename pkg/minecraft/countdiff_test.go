@@ -0,0 +1,66 @@
+package minecraft_test
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldChunkBlocksCountDiffsBetweenWorlds(t *testing.T) {
+	before, err := mc.OpenWorld(mockFS{MapFS: fstest.MapFS{
+		"world/region/r.0.0.mca": &fstest.MapFile{Data: buildRegion(t, solidChunk(0, 0, "minecraft:stone"), solidChunk(1, 0, "minecraft:diamond_ore"))},
+	}}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer before.Close()
+
+	after, err := mc.OpenWorld(mockFS{MapFS: fstest.MapFS{
+		"world/region/r.0.0.mca": &fstest.MapFile{Data: buildRegion(t, solidChunk(0, 0, "minecraft:stone"), solidChunk(1, 0, "minecraft:hopper"), solidChunk(2, 0, "minecraft:stone"))},
+	}}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer after.Close()
+
+	beforeCounts, err := before.BlocksCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	afterChunks, err := after.ChunkBlocksCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedChunks := map[mc.ChunkPos]map[string]uint64{
+		{X: 0, Z: 0}: {"minecraft:stone": 4096},
+		{X: 1, Z: 0}: {"minecraft:hopper": 4096},
+		{X: 2, Z: 0}: {"minecraft:stone": 4096},
+	}
+	if !reflect.DeepEqual(afterChunks[mc.Overworld], expectedChunks) {
+		t.Errorf("expected %v, got %v", expectedChunks, afterChunks[mc.Overworld])
+	}
+
+	afterCounts, err := after.BlocksCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deltas := mc.DiffCounts(beforeCounts[mc.Overworld], afterCounts[mc.Overworld])
+	expected := map[string]int64{"minecraft:diamond_ore": -4096, "minecraft:hopper": 4096, "minecraft:stone": 4096}
+	if !reflect.DeepEqual(deltas, expected) {
+		t.Errorf("expected %v, got %v", expected, deltas)
+	}
+
+	within, err := after.ChunkBlocksCount(mc.WithinArea(mc.Box(mc.BlockPos{X: 16, Y: 0, Z: 0}, mc.BlockPos{X: 16, Y: 1, Z: 1})))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(within[mc.Overworld], map[mc.ChunkPos]map[string]uint64{{X: 1, Z: 0}: {"minecraft:hopper": 4}}) {
+		t.Errorf("expected only the blocks within the area, got %v", within[mc.Overworld])
+	}
+}
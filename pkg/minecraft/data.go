@@ -0,0 +1,91 @@
+package minecraft
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+// DataFile reads the NBT data file with the given name, such as raids or
+// map_0, from the data directory of the given dimension. An empty
+// dimension is the overworld, which is where most data files are kept.
+func (w World) DataFile(name, dimension string) (nbtree.Field, error) {
+	data, err := w.readDataFile(name, dimension)
+	if err != nil {
+		return nbtree.Field{}, err
+	}
+
+	root, err := nbtree.Read(bytes.NewReader(data))
+	if err != nil {
+		return nbtree.Field{}, fmt.Errorf("unable to read %s NBT data: %w", name, err)
+	}
+
+	return root, nil
+}
+
+// decodeDataFile decodes the given data file's NBT data into v.
+func (w World) decodeDataFile(name, dimension string, v any) error {
+	data, err := w.readDataFile(name, dimension)
+	if err != nil {
+		return err
+	}
+
+	if err := nbt.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unable to read %s NBT data: %w", name, err)
+	}
+
+	return nil
+}
+
+// readDataFile returns the given data file's uncompressed NBT data.
+func (w World) readDataFile(name, dimension string) ([]byte, error) {
+	if len(dimension) == 0 {
+		dimension = Overworld
+	}
+
+	dim, ok := w.Dimension(ParseDimensionID(dimension))
+	if !ok {
+		return nil, fmt.Errorf("dimension %s not found in world %s", dimension, w.name)
+	}
+
+	if !strings.HasSuffix(name, ".dat") {
+		name += ".dat"
+	}
+
+	// data files are kept directly within the data directory
+	if filepath.Base(name) != name {
+		return nil, fmt.Errorf("%s is not a data file name", name)
+	}
+
+	data, err := fs.ReadFile(w.fsys, filepath.Join(dim.path, "data", name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("data file %s not found in %s: %w", name, dim.ID, err)
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", name, err)
+	}
+
+	// the game compresses every data file, but tools don't always
+	_, r, err := detectCompression(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress %s: %w", name, err)
+	}
+
+	data, err = io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress %s: %w", name, err)
+	}
+
+	return data, nil
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
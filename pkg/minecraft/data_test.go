@@ -0,0 +1,176 @@
+package minecraft_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldRaidsReadsEachDimensionsFile(t *testing.T) {
+	type raid struct {
+		ID                 int32 `nbt:"Id"`
+		Status             string
+		CX, CY, CZ         int32
+		Started            byte
+		BadOmenLevel       int32
+		HeroesOfTheVillage [][4]int32
+	}
+
+	type raids struct {
+		Data struct {
+			Raids []raid
+		} `nbt:"data"`
+	}
+
+	overworld := raids{}
+	overworld.Data.Raids = []raid{{ID: 2, Status: "ongoing", CX: 100, CY: 64, CZ: -20, Started: 1, BadOmenLevel: 3, HeroesOfTheVillage: [][4]int32{{1, 2, 3, 4}}}}
+
+	end := raids{}
+	end.Data.Raids = []raid{{ID: 7, Status: "victory"}}
+
+	fsys := fstest.MapFS{
+		"world/data/raids.dat":          &fstest.MapFile{Data: gzipNBT(t, overworld)},
+		"world/DIM1/data/raids_end.dat": &fstest.MapFile{Data: gzipNBT(t, end)},
+	}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	got, err := world.Raids("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 1 || got[0].ID != 2 || !got[0].Started || got[0].Center != (mc.BlockPos{X: 100, Y: 64, Z: -20}) {
+		t.Fatalf("unexpected raids %+v", got)
+	}
+
+	if len(got[0].Heroes) != 1 || got[0].Heroes[0] != "00000001-0000-0002-0000-000300000004" {
+		t.Errorf("unexpected heroes %v", got[0].Heroes)
+	}
+
+	got, err = world.Raids("end")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Status != "victory" {
+		t.Errorf("unexpected end raids %+v", got)
+	}
+
+	if _, err := world.Raids("nether"); err == nil {
+		t.Error("expected an error for a dimension which doesn't exist")
+	}
+}
+
+func TestWorldDataFileReadsScoreboardAndMaps(t *testing.T) {
+	type objective struct {
+		Name         string
+		CriteriaName string
+	}
+
+	type score struct {
+		Name      string
+		Objective string
+		Score     int32
+	}
+
+	type scoreboard struct {
+		Data struct {
+			Objectives   []objective
+			PlayerScores []score
+			DisplaySlots map[string]string
+		} `nbt:"data"`
+	}
+
+	type mapData struct {
+		Data struct {
+			Dimension string `nbt:"dimension"`
+			Scale     byte   `nbt:"scale"`
+			XCenter   int32  `nbt:"xCenter"`
+			ZCenter   int32  `nbt:"zCenter"`
+			Colors    []byte `nbt:"colors"`
+		} `nbt:"data"`
+	}
+
+	sb := scoreboard{}
+	sb.Data.Objectives = []objective{{Name: "deaths", CriteriaName: "deathCount"}}
+	sb.Data.PlayerScores = []score{
+		{Name: "alex", Objective: "deaths", Score: 2},
+		{Name: "steve", Objective: "deaths", Score: 9},
+	}
+	sb.Data.DisplaySlots = map[string]string{"slot_1": "deaths"}
+
+	m := mapData{}
+	m.Data.Dimension = mc.Overworld
+	m.Data.Scale = 2
+	m.Data.XCenter, m.Data.ZCenter = 64, -448
+	m.Data.Colors = make([]byte, 128*128)
+
+	fsys := fstest.MapFS{
+		"world/data/scoreboard.dat": &fstest.MapFile{Data: gzipNBT(t, sb)},
+		"world/data/map_3.dat":      &fstest.MapFile{Data: gzipNBT(t, m)},
+		"world/data/map_10.dat":     &fstest.MapFile{Data: gzipNBT(t, m)},
+	}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	got, err := world.Scoreboard()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got.Objectives) != 1 || got.Objectives[0].Criteria != "deathCount" || got.DisplaySlots["slot_1"] != "deaths" {
+		t.Errorf("unexpected scoreboard %+v", got)
+	}
+
+	if len(got.Scores) != 2 || got.Scores[0].Name != "steve" {
+		t.Errorf("expected scores highest first, got %+v", got.Scores)
+	}
+
+	maps, err := world.Maps()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(maps) != 2 || maps[0].ID != 3 || maps[1].ID != 10 {
+		t.Fatalf("expected maps sorted by ID, got %d maps", len(maps))
+	}
+
+	if maps[0].Scale != 2 || maps[0].CenterZ != -448 || len(maps[0].Colors) != 128*128 {
+		t.Errorf("unexpected map %+v", maps[0])
+	}
+
+	root, err := world.DataFile("map_3", "overworld")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, ok := root.Compound().Get("data")
+	if !ok {
+		t.Fatal("expected data compound within map_3")
+	}
+
+	if _, ok := data.Compound().Get("xCenter"); !ok {
+		t.Error("expected xCenter within map_3 data")
+	}
+
+	if _, err := world.DataFile("command_storage_minecraft", ""); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected missing data file to be not exist, got %v", err)
+	}
+
+	fsys["world/level.dat"] = &fstest.MapFile{Data: gzipNBT(t, m)}
+	if _, err := world.DataFile("../level", ""); err == nil {
+		t.Error("expected data file names outside the data directory to be rejected")
+	}
+}
@@ -0,0 +1,190 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/tauraamui/mcscan/internal/vfs"
+)
+
+const (
+	Overworld = "minecraft:overworld"
+	Nether    = "minecraft:the_nether"
+	End       = "minecraft:the_end"
+)
+
+// vanillaDimensions maps each vanilla dimension to its directory
+// relative to the world root, the overworld lives in the root itself.
+var vanillaDimensions = []struct {
+	id  string
+	dir string
+}{
+	{id: Overworld, dir: ""},
+	{id: Nether, dir: "DIM-1"},
+	{id: End, dir: "DIM1"},
+}
+
+type Dimension struct {
+	ID            string
+	path          string
+	regions       []region
+	entityRegions []region
+	poiRegions    []region
+}
+
+func (d Dimension) Path() string {
+	return d.path
+}
+
+func (d Dimension) RegionsCount() int {
+	return len(d.regions)
+}
+
+func (d Dimension) EntityRegionsCount() int {
+	return len(d.entityRegions)
+}
+
+func (d Dimension) POIRegionsCount() int {
+	return len(d.poiRegions)
+}
+
+// ParseDimensionID resolves the given dimension reference into a fully
+// namespaced dimension ID, accepting short names such as "nether" or "end".
+func ParseDimensionID(ref string) string {
+	switch strings.ToLower(ref) {
+	case "overworld":
+		return Overworld
+	case "nether", "the_nether", "dim-1":
+		return Nether
+	case "end", "the_end", "dim1":
+		return End
+	}
+
+	if !strings.Contains(ref, ":") {
+		return "minecraft:" + ref
+	}
+
+	return ref
+}
+
+func (w *World) resolveDimensions() error {
+	for _, vd := range vanillaDimensions {
+		dimPath := filepath.Join(w.path, vd.dir)
+		if len(vd.dir) > 0 {
+			exists, err := w.dirExists(dimPath)
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				continue
+			}
+		}
+
+		if err := w.addDimension(vd.id, dimPath); err != nil {
+			return err
+		}
+	}
+
+	// datapack defined dimensions live under dimensions/<namespace>/<name>
+	customDims, err := vfs.Glob(w.fsys, filepath.Join(w.path, "dimensions", "*", "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, dimPath := range customDims {
+		exists, err := w.dirExists(dimPath)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			continue
+		}
+
+		name := filepath.Base(dimPath)
+		namespace := filepath.Base(filepath.Dir(dimPath))
+		if err := w.addDimension(fmt.Sprintf("%s:%s", namespace, name), dimPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *World) addDimension(id, path string) error {
+	regions, err := w.resolveRegions(filepath.Join(path, "region"))
+	if err != nil {
+		return err
+	}
+
+	// since 1.17 entities are stored separately from the terrain
+	entityRegions, err := w.resolveRegions(filepath.Join(path, "entities"))
+	if err != nil {
+		return err
+	}
+
+	poiRegions, err := w.resolveRegions(filepath.Join(path, "poi"))
+	if err != nil {
+		return err
+	}
+
+	w.dimensions = append(w.dimensions, Dimension{
+		ID:            id,
+		path:          path,
+		regions:       regions,
+		entityRegions: entityRegions,
+		poiRegions:    poiRegions,
+	})
+
+	return nil
+}
+
+func (w *World) resolveRegions(dir string) ([]region, error) {
+	found, err := vfs.Glob(w.fsys, filepath.Join(dir, "*.mca"))
+	if err != nil {
+		return nil, err
+	}
+
+	regions := make([]region, 0, len(found))
+	for _, f := range found {
+		// stray copies such as "r.0.0 (1).mca" are common in real saves,
+		// the game ignores them and so do we
+		pos, err := parseRegionPos(f)
+		if err != nil {
+			continue
+		}
+		regions = append(regions, region{fsys: w.fsys, path: f, pos: pos})
+	}
+
+	return regions, nil
+}
+
+func (w *World) dirExists(path string) (bool, error) {
+	fi, err := w.fsys.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return fi.IsDir(), nil
+}
+
+func (w World) Dimensions() []Dimension {
+	return w.dimensions
+}
+
+func (w World) Dimension(id string) (Dimension, bool) {
+	for _, dim := range w.dimensions {
+		if dim.ID == id {
+			return dim, true
+		}
+	}
+
+	return Dimension{}, false
+}
@@ -0,0 +1,200 @@
+package minecraft
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+type EntityPos struct {
+	X, Y, Z float64
+}
+
+// BlockPos returns the position of the block the entity is within.
+func (p EntityPos) BlockPos() BlockPos {
+	return BlockPos{X: int(math.Floor(p.X)), Y: int(math.Floor(p.Y)), Z: int(math.Floor(p.Z))}
+}
+
+type Entity struct {
+	ID         string
+	UUID       string
+	Pos        EntityPos
+	Chunk      ChunkPos
+	CustomName string
+	Health     float32
+	// Item is the stack of items an item entity is made of, nil for
+	// every other type of entity.
+	Item       *Item
+	Passengers []Entity
+}
+
+// CountEntities returns the total of each entity ID across the given
+// entities, including their passengers.
+func CountEntities(entities []Entity) map[string]uint64 {
+	counts := map[string]uint64{}
+	countEntities(entities, counts)
+	return counts
+}
+
+func countEntities(entities []Entity, counts map[string]uint64) {
+	for _, e := range entities {
+		counts[e.ID]++
+		countEntities(e.Passengers, counts)
+	}
+}
+
+type entityChunkTag struct {
+	Entities []entityTag
+}
+
+type entityTag struct {
+	ID         string `nbt:"id"`
+	UUID       [4]int32
+	Pos        []float64
+	CustomName string
+	// Health is a short for item entities, but a float for mobs
+	Health     any
+	Item       *itemTag
+	Passengers []entityTag
+}
+
+func (t entityTag) entity(chunk ChunkPos) Entity {
+	e := Entity{
+		ID:         t.ID,
+		UUID:       formatUUID(t.UUID),
+		Chunk:      chunk,
+		CustomName: t.CustomName,
+		Health:     numberAsFloat32(t.Health),
+	}
+
+	if len(t.Pos) == 3 {
+		e.Pos = EntityPos{X: t.Pos[0], Y: t.Pos[1], Z: t.Pos[2]}
+	}
+
+	if t.Item != nil {
+		item := t.Item.item()
+		e.Item = &item
+	}
+
+	for _, p := range t.Passengers {
+		e.Passengers = append(e.Passengers, p.entity(chunk))
+	}
+
+	return e
+}
+
+func numberAsFloat32(v any) float32 {
+	switch n := v.(type) {
+	case int8:
+		return float32(n)
+	case int16:
+		return float32(n)
+	case int32:
+		return float32(n)
+	case float32:
+		return n
+	case float64:
+		return float32(n)
+	}
+	return 0
+}
+
+// formatUUID formats a UUID stored as four ints, the way the game
+// stores them, into its canonical hyphenated form.
+func formatUUID(id [4]int32) string {
+	return fmt.Sprintf(
+		"%08x-%04x-%04x-%04x-%04x%08x",
+		uint32(id[0]), uint32(id[1])>>16, uint32(id[1])&0xFFFF,
+		uint32(id[2])>>16, uint32(id[2])&0xFFFF, uint32(id[3]),
+	)
+}
+
+// parseUUID normalises the given UUID, with or without hyphens, into the
+// lower case, hyphenated form player files are named by.
+func parseUUID(id string) (string, error) {
+	hex := strings.ToLower(strings.ReplaceAll(id, "-", ""))
+	valid := len(hex) == 32 && (len(id) == 32 || len(id) == 36)
+	for _, c := range hex {
+		valid = valid && (c >= '0' && c <= '9' || c >= 'a' && c <= 'f')
+	}
+
+	if !valid {
+		return "", fmt.Errorf("invalid player UUID %q, expected e.g. 480c70ff-1bf6-44e3-8e42-f365f2d4fbef", id)
+	}
+
+	formatted := hex[:8] + "-" + hex[8:12] + "-" + hex[12:16] + "-" + hex[16:20] + "-" + hex[20:]
+	if len(id) == 36 && strings.ToLower(id) != formatted {
+		return "", fmt.Errorf("invalid player UUID %q, expected e.g. 480c70ff-1bf6-44e3-8e42-f365f2d4fbef", id)
+	}
+
+	return formatted, nil
+}
+
+// appendChunkEntities decodes the given entities chunk, appending every
+// entity within area if given.
+func appendChunkEntities(entities []Entity, cd chunkData, area *Area) ([]Entity, error) {
+	r, err := chunkNBTReader(cd.data)
+	if err != nil {
+		return nil, err
+	}
+
+	var ec entityChunkTag
+	if _, err := nbt.NewDecoder(r).Decode(&ec); err != nil {
+		return nil, fmt.Errorf("unable to load entities NBT data: %w", err)
+	}
+
+	for _, et := range ec.Entities {
+		e := et.entity(cd.pos)
+		if area != nil && !area.Contains(e.Pos.BlockPos()) {
+			continue
+		}
+		entities = append(entities, e)
+	}
+
+	return entities, nil
+}
+
+// Entities returns every entity stored within the world's entities
+// region files, keyed by the ID of the dimension they were found in.
+func (w World) Entities(opts ...ScanOption) (map[string][]Entity, error) {
+	cfg := resolveScanConfig(opts)
+	dims, err := w.selectDimensions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string][]Entity{}
+	for _, dim := range dims {
+		workerEntities := []*[]Entity{}
+		err := w.scanChunks(cfg, dim.entityRegions, func() chunkWorker {
+			entities := []Entity{}
+			workerEntities = append(workerEntities, &entities)
+			return func(cd chunkData) (err error) {
+				entities, err = appendChunkEntities(entities, cd, cfg.area)
+				return err
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		entities := []Entity{}
+		for _, we := range workerEntities {
+			entities = append(entities, *we...)
+		}
+
+		sort.Slice(entities, func(i, j int) bool {
+			a, b := entities[i], entities[j]
+			if a.ID != b.ID {
+				return a.ID < b.ID
+			}
+			return a.UUID < b.UUID
+		})
+		found[dim.ID] = entities
+	}
+
+	return found, nil
+}
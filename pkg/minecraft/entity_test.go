@@ -0,0 +1,66 @@
+package minecraft_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldEntitiesIncludesPassengers(t *testing.T) {
+	type entity struct {
+		ID         string `nbt:"id"`
+		UUID       [4]int32
+		Pos        []float64
+		Health     float32
+		Passengers []entity `nbt:"Passengers,omitempty"`
+	}
+
+	fsys := fstest.MapFS{
+		"world/entities/r.-1.0.mca": &fstest.MapFile{Data: buildRegion(t, testChunk{
+			XPos: -1, ZPos: 3,
+			Entities: []entity{
+				{
+					ID: "minecraft:spider", UUID: [4]int32{1, 2, 3, 4}, Pos: []float64{-10.5, 64, 50.25}, Health: 16,
+					Passengers: []entity{
+						{ID: "minecraft:skeleton", UUID: [4]int32{5, 6, 7, 8}, Pos: []float64{-10.5, 65, 50.25}, Health: 20},
+					},
+				},
+			},
+		})},
+	}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	found, err := world.Entities()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entities := found[mc.Overworld]
+	if len(entities) != 1 {
+		t.Fatalf("expected 1 entity, got %d", len(entities))
+	}
+
+	spider := entities[0]
+	if spider.UUID != "00000001-0000-0002-0000-000300000004" {
+		t.Errorf("unexpected UUID %s", spider.UUID)
+	}
+
+	if spider.Chunk != (mc.ChunkPos{X: -1, Z: 3}) {
+		t.Errorf("unexpected chunk %+v", spider.Chunk)
+	}
+
+	if spider.Pos.BlockPos() != (mc.BlockPos{X: -11, Y: 64, Z: 50}) {
+		t.Errorf("unexpected block position %+v", spider.Pos.BlockPos())
+	}
+
+	counts := mc.CountEntities(entities)
+	if counts["minecraft:spider"] != 1 || counts["minecraft:skeleton"] != 1 {
+		t.Errorf("expected passengers to be counted, got %v", counts)
+	}
+}
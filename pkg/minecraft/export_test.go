@@ -0,0 +1,9 @@
+package minecraft
+
+// MemFile exposes memFile to build region files within in tests.
+type MemFile = memFile
+
+// Data returns everything written to the file.
+func (f *memFile) Data() []byte {
+	return f.data
+}
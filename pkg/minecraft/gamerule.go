@@ -0,0 +1,203 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+// ErrUnknownGameRule is returned when setting a game rule which isn't
+// one of the vanilla game rules without being forced to.
+var ErrUnknownGameRule = errors.New("unknown game rule")
+
+type GameRuleType int
+
+const (
+	BoolGameRule GameRuleType = iota + 1
+	IntGameRule
+)
+
+func (t GameRuleType) String() string {
+	switch t {
+	case BoolGameRule:
+		return "boolean"
+	case IntGameRule:
+		return "integer"
+	}
+	return "unknown"
+}
+
+func (t GameRuleType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+type GameRule struct {
+	Name    string
+	Type    GameRuleType
+	Default string
+}
+
+// Parse validates the given value for the game rule, returning it as the
+// game stores it. Values of rules of an unknown type are kept as given.
+func (r GameRule) Parse(value string) (string, error) {
+	switch r.Type {
+	case IntGameRule:
+		v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("game rule %s must be an integer, got %s", r.Name, value)
+		}
+		return strconv.FormatInt(v, 10), nil
+	case BoolGameRule:
+		v, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("game rule %s must be true or false, got %s", r.Name, value)
+		}
+		return strconv.FormatBool(v), nil
+	}
+	return value, nil
+}
+
+// vanillaGameRules are the game rules of 1.19.4 and their defaults.
+var vanillaGameRules = map[string]GameRule{}
+
+func init() {
+	for name, def := range map[string]bool{
+		"announceAdvancements": true, "blockExplosionDropDecay": true,
+		"commandBlockOutput": true, "disableElytraMovementCheck": false,
+		"disableRaids": false, "doDaylightCycle": true, "doEntityDrops": true,
+		"doFireTick": true, "doImmediateRespawn": false, "doInsomnia": true,
+		"doLimitedCrafting": false, "doMobLoot": true, "doMobSpawning": true,
+		"doPatrolSpawning": true, "doTileDrops": true, "doTraderSpawning": true,
+		"doVinesSpread": true, "doWardenSpawning": true, "doWeatherCycle": true,
+		"drowningDamage": true, "fallDamage": true, "fireDamage": true,
+		"forgiveDeadPlayers": true, "freezeDamage": true, "globalSoundEvents": true,
+		"keepInventory": false, "lavaSourceConversion": false, "logAdminCommands": true,
+		"mobExplosionDropDecay": true, "mobGriefing": true, "naturalRegeneration": true,
+		"reducedDebugInfo": false, "sendCommandFeedback": true, "showDeathMessages": true,
+		"spectatorsGenerateChunks": true, "tntExplosionDropDecay": false,
+		"universalAnger": false, "waterSourceConversion": true,
+	} {
+		vanillaGameRules[name] = GameRule{Name: name, Type: BoolGameRule, Default: strconv.FormatBool(def)}
+	}
+
+	for name, def := range map[string]int{
+		"commandModificationBlockLimit": 32768, "maxCommandChainLength": 65536,
+		"maxEntityCramming": 24, "playersSleepingPercentage": 100,
+		"randomTickSpeed": 3, "snowAccumulationHeight": 1, "spawnRadius": 10,
+	} {
+		vanillaGameRules[name] = GameRule{Name: name, Type: IntGameRule, Default: strconv.Itoa(def)}
+	}
+}
+
+// LookupGameRule returns the vanilla game rule with the given name.
+func LookupGameRule(name string) (GameRule, bool) {
+	r, ok := vanillaGameRules[name]
+	return r, ok
+}
+
+// GameRuleValue is a game rule's value within a world. Rules which aren't
+// vanilla, such as those added by mods, have no type or default.
+type GameRuleValue struct {
+	GameRule
+	Value   string
+	Vanilla bool
+}
+
+// Modified reports whether a vanilla game rule differs from its default.
+func (v GameRuleValue) Modified() bool {
+	return v.Vanilla && v.Value != v.Default
+}
+
+// GameRules returns the value of every game rule, sorted by name. Vanilla
+// rules missing from the level data have their default value.
+func (w World) GameRules() ([]GameRuleValue, error) {
+	f, err := w.ReadNBT("level.dat")
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[string]string{}
+	if rules, err := nbtree.Get(f.Root.Node, nbtree.Path{{Name: "Data"}, {Name: "GameRules"}}); err == nil {
+		if c := rules.Compound(); c != nil {
+			for _, field := range c.Fields {
+				if v, ok := field.Value.(string); ok {
+					set[field.Name] = v
+				}
+			}
+		}
+	}
+
+	values := make([]GameRuleValue, 0, len(vanillaGameRules))
+	for name, r := range vanillaGameRules {
+		v, ok := set[name]
+		if !ok {
+			v = r.Default
+		}
+		values = append(values, GameRuleValue{GameRule: r, Value: v, Vanilla: true})
+	}
+
+	for name, v := range set {
+		if _, ok := vanillaGameRules[name]; !ok {
+			values = append(values, GameRuleValue{GameRule: GameRule{Name: name}, Value: v})
+		}
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+
+	return values, nil
+}
+
+// GameRule returns the value of the game rule with the given name, the
+// error wraps nbtree.ErrNotFound if it's neither vanilla nor set.
+func (w World) GameRule(name string) (GameRuleValue, error) {
+	values, err := w.GameRules()
+	if err != nil {
+		return GameRuleValue{}, err
+	}
+
+	for _, v := range values {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+
+	return GameRuleValue{}, fmt.Errorf("game rule %s: %w", name, nbtree.ErrNotFound)
+}
+
+// SetGameRule sets the game rule with the given name, validating the value
+// against the rule's type. Game rules which aren't vanilla are only set if
+// forced, as the game would discard misspelt ones.
+func SetGameRule(name, value string, force bool) LevelEdit {
+	return func(data nbtree.Node) error {
+		r, ok := vanillaGameRules[name]
+		if !ok && !force {
+			return fmt.Errorf("%w %s", ErrUnknownGameRule, name)
+		}
+
+		v, err := r.Parse(value)
+		if err != nil {
+			return err
+		}
+
+		c := data.Compound()
+		rules, ok := c.Get("GameRules")
+		if !ok {
+			rules = nbtree.NewCompound(&nbtree.Compound{})
+			c.Set("GameRules", rules)
+		}
+
+		if rules.Compound() == nil {
+			return fmt.Errorf("GameRules is a %s rather than a compound", nbtree.TypeName(rules.Type))
+		}
+
+		// the game stores every rule as a string, whatever its type
+		rules.Compound().Set(name, nbtree.String(v))
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package minecraft_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldSetGameRuleValidatesAgainstVanillaRules(t *testing.T) {
+	type data struct {
+		GameRules map[string]string
+	}
+
+	fsys := mockFS{MapFS: fstest.MapFS{"world/level.dat": &fstest.MapFile{Data: gzipNBT(t, struct{ Data data }{data{
+		GameRules: map[string]string{"keepInventory": "false", "mod:rule": "x"},
+	}})}}}
+
+	world, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	err = world.EditLevel(mc.SetGameRule("keepInventory", "TRUE", false), mc.SetGameRule("randomTickSpeed", "10", false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, bad := range []struct{ name, value string }{
+		{"keepInventory", "yes"},
+		{"randomTickSpeed", "1.5"},
+		{"keepInv", "true"},
+	} {
+		if err := world.EditLevel(mc.SetGameRule(bad.name, bad.value, false)); err == nil {
+			t.Errorf("expected an error setting %s to %s", bad.name, bad.value)
+		}
+	}
+
+	if err := world.EditLevel(mc.SetGameRule("keepInv", "true", false)); !errors.Is(err, mc.ErrUnknownGameRule) {
+		t.Errorf("expected unknown game rule error, got %v", err)
+	}
+
+	rules, err := world.GameRules()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	modified := map[string]string{}
+	for _, r := range rules {
+		if r.Modified() {
+			modified[r.Name] = r.Value
+		}
+	}
+
+	if len(modified) != 2 || modified["keepInventory"] != "true" || modified["randomTickSpeed"] != "10" {
+		t.Errorf("unexpected modified game rules %v", modified)
+	}
+
+	r, err := world.GameRule("mod:rule")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.Vanilla || r.Value != "x" {
+		t.Errorf("unexpected game rule %+v", r)
+	}
+
+	if r, err := world.GameRule("doFireTick"); err != nil || r.Value != "true" || r.Type != mc.BoolGameRule {
+		t.Errorf("expected doFireTick to default to true, got %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,97 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+// ErrTagTypeChange is returned by edits which would change the type of an
+// existing tag without being forced to.
+var ErrTagTypeChange = errors.New("tag type would change")
+
+// LevelEdit modifies the level's raw data, the Data compound within
+// level.dat, any tag it doesn't touch is written back exactly as it was read.
+type LevelEdit func(data nbtree.Node) error
+
+// SetLevelValue sets the tag at the given path within the level data,
+// such as DayTime or GameRules.keepInventory, to value parsed as the tag's
+// existing type. The value is parsed as typ instead when it's given, but
+// adding a tag or changing the type of an existing one is only done if
+// forced, a new tag without a type has it inferred from the value as SNBT.
+// Without force, missing tags fail with an error wrapping
+// nbtree.ErrNotFound.
+func SetLevelValue(path, value, typ string, force bool) LevelEdit {
+	return func(data nbtree.Node) error {
+		p, err := nbtree.ParsePath(path)
+		if err != nil {
+			return err
+		}
+
+		n, err := parseTagValue(data, p, value, typ, force)
+		if err != nil {
+			return err
+		}
+
+		return nbtree.Set(data, p, n)
+	}
+}
+
+// parseTagValue parses value as the type of the tag at path within root,
+// or as typ if given.
+func parseTagValue(root nbtree.Node, path nbtree.Path, value, typ string, force bool) (nbtree.Node, error) {
+	existing, err := nbtree.Get(root, path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, nbtree.ErrNotFound) {
+		return nbtree.Node{}, err
+	}
+
+	if !exists && !force {
+		return nbtree.Node{}, err
+	}
+
+	if len(typ) == 0 {
+		if !exists {
+			return nbtree.ParseSNBT(value)
+		}
+		return nbtree.ParseValue(existing.Type, value)
+	}
+
+	t, err := nbtree.ParseType(typ)
+	if err != nil {
+		return nbtree.Node{}, err
+	}
+
+	if exists && t != existing.Type && !force {
+		return nbtree.Node{}, fmt.Errorf("%s: %w from %s to %s", path, ErrTagTypeChange, nbtree.TypeName(existing.Type), nbtree.TypeName(t))
+	}
+
+	return nbtree.ParseValue(t, value)
+}
+
+// EditLevel applies the given edits to the level data, keeping the level
+// data as it was before as level.dat_old, the same as the game does.
+func (w World) EditLevel(edits ...LevelEdit) error {
+	f, err := w.ReadNBT("level.dat")
+	if err != nil {
+		return err
+	}
+
+	var data nbtree.Node
+	if root := f.Root.Compound(); root != nil {
+		data, _ = root.Get("Data")
+	}
+
+	if data.Compound() == nil {
+		return errors.New("level.dat has no Data compound")
+	}
+
+	for _, edit := range edits {
+		if err := edit(data); err != nil {
+			return err
+		}
+	}
+
+	return w.WriteNBT(f)
+}
@@ -0,0 +1,72 @@
+package minecraft_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tauraamui/mcscan/internal/nbtree"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldEditLevelKeepsTagTypes(t *testing.T) {
+	type data struct {
+		DayTime    int64
+		Difficulty int8
+		GameRules  struct {
+			KeepInventory string `nbt:"keepInventory"`
+		}
+		Unknown string `nbt:"mod:data"`
+	}
+
+	var d data
+	d.DayTime = 6000
+	d.Difficulty = 2
+	d.GameRules.KeepInventory = "false"
+	d.Unknown = "kept"
+
+	original := gzipNBT(t, struct{ Data data }{d})
+	fsys := mockFS{MapFS: fstest.MapFS{"world/level.dat": &fstest.MapFile{Data: original}}}
+
+	world, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	err = world.EditLevel(
+		mc.SetLevelValue("DayTime", "18000", "", false),
+		mc.SetLevelValue("Difficulty", "3", "", false),
+		mc.SetLevelValue("GameRules.keepInventory", "true", "", false),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(fsys.MapFS["world/level.dat_old"].Data, original) {
+		t.Error("expected the original data to be kept as level.dat_old")
+	}
+
+	f, err := world.ReadNBT("level.dat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{Data:{DayTime:18000L,Difficulty:3b,GameRules:{keepInventory:"true"},"mod:data":"kept"}}`
+	if got := nbtree.FormatSNBT(f.Root.Node, ""); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	if err := world.EditLevel(mc.SetLevelValue("DayTime", "1", "int", false)); !errors.Is(err, mc.ErrTagTypeChange) {
+		t.Errorf("expected type change error, got %v", err)
+	}
+
+	if err := world.EditLevel(mc.SetLevelValue("GameRules.mod", "1", "", false)); !errors.Is(err, nbtree.ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if err := world.EditLevel(mc.SetLevelValue("GameRules.mod", "1b", "", true)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package minecraft_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tauraamui/mcscan/internal/filesystem"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldInUseIsReadOnlyUnlessLockIgnored(t *testing.T) {
+	type data struct {
+		DayTime int64
+	}
+
+	fsys := mockFS{MapFS: fstest.MapFS{
+		"world/level.dat":    &fstest.MapFile{Data: gzipNBT(t, struct{ Data data }{data{DayTime: 6000}})},
+		"world/session.lock": &fstest.MapFile{Data: []byte("☃")},
+	}}
+
+	// stands in for the game having the world open
+	game, err := filesystem.Lock(fsys, "world/session.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	world, err := mc.OpenWorld(fsys, "world", mc.WithSessionLock())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !world.InUse() {
+		t.Error("expected world to be in use")
+	}
+
+	if err := world.EditLevel(mc.SetLevelValue("DayTime", "1", "", false)); !errors.Is(err, mc.ErrWorldInUse) {
+		t.Errorf("expected world in use error, got %v", err)
+	}
+	world.Close()
+
+	// without WithSessionLock the lock is still taken to write
+	unlocked, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := unlocked.EditLevel(mc.SetLevelValue("DayTime", "1", "", false)); !errors.Is(err, mc.ErrWorldInUse) {
+		t.Errorf("expected world in use error, got %v", err)
+	}
+	unlocked.Close()
+
+	ignored, err := mc.OpenWorld(fsys, "world", mc.WithIgnoreLock())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := ignored.EditLevel(mc.SetLevelValue("DayTime", "1", "", false)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	ignored.Close()
+	game.Close()
+
+	// reading leaves the lock for the game to take
+	reader, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	relock, err := filesystem.Lock(fsys, "world/session.lock")
+	if err != nil {
+		t.Fatalf("expected a world opened without WithSessionLock not to hold the lock, got %v", err)
+	}
+	relock.Close()
+	reader.Close()
+
+	world, err = mc.OpenWorld(fsys, "world", mc.WithSessionLock())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if world.InUse() {
+		t.Error("expected world not to be in use once the game closed it")
+	}
+
+	if _, err := filesystem.Lock(fsys, "world/session.lock"); !errors.Is(err, filesystem.ErrLocked) {
+		t.Errorf("expected the open world to hold the lock, got %v", err)
+	}
+
+	world.Close()
+	relock, err = filesystem.Lock(fsys, "world/session.lock")
+	if err != nil {
+		t.Fatalf("expected the lock to be released once the world closed, got %v", err)
+	}
+	relock.Close()
+}
@@ -0,0 +1,121 @@
+package minecraft
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tauraamui/mcscan/internal/vfs"
+)
+
+// MapBanner is a banner marked on a map.
+type MapBanner struct {
+	Pos   BlockPos
+	Color string
+	Name  string
+}
+
+// MapData is a filled map item's data, kept in data/map_<id>.dat.
+type MapData struct {
+	ID        int
+	Dimension string
+	// Scale is how zoomed out the map is, from 0 at one block per pixel to
+	// 4 at 16 blocks per pixel.
+	Scale             int
+	CenterX, CenterZ  int
+	Locked            bool
+	TrackingPosition  bool
+	UnlimitedTracking bool
+	Banners           []MapBanner
+	// Colors holds the colour of each of the map's 128 by 128 pixels.
+	Colors []byte `json:"-"`
+}
+
+type mapDataTag struct {
+	Data struct {
+		Dimension         string `nbt:"dimension"`
+		Scale             int32  `nbt:"scale"`
+		XCenter           int32  `nbt:"xCenter"`
+		ZCenter           int32  `nbt:"zCenter"`
+		Locked            bool   `nbt:"locked"`
+		TrackingPosition  bool   `nbt:"trackingPosition"`
+		UnlimitedTracking bool   `nbt:"unlimitedTracking"`
+		Banners           []struct {
+			Color string
+			Name  string
+			Pos   struct {
+				X, Y, Z int32
+			}
+		} `nbt:"banners"`
+		Colors []byte `nbt:"colors"`
+	} `nbt:"data"`
+}
+
+// Maps returns the data of every map which has been filled in, sorted by
+// ID.
+func (w World) Maps() ([]MapData, error) {
+	found, err := vfs.Glob(w.fsys, filepath.Join(w.path, "data", "map_*.dat"))
+	if err != nil {
+		return nil, err
+	}
+
+	maps := make([]MapData, 0, len(found))
+	for _, f := range found {
+		id, ok := mapIDFromName(filepath.Base(f))
+		if !ok {
+			continue
+		}
+
+		m, err := w.Map(id)
+		if err != nil {
+			return nil, err
+		}
+		maps = append(maps, m)
+	}
+
+	sort.Slice(maps, func(i, j int) bool {
+		return maps[i].ID < maps[j].ID
+	})
+
+	return maps, nil
+}
+
+// Map returns the data of the map with the given ID.
+func (w World) Map(id int) (MapData, error) {
+	var mt mapDataTag
+	if err := w.decodeDataFile(fmt.Sprintf("map_%d", id), Overworld, &mt); err != nil {
+		return MapData{}, fmt.Errorf("unable to read map %d: %w", id, err)
+	}
+
+	m := MapData{
+		ID:                id,
+		Dimension:         mt.Data.Dimension,
+		Scale:             int(mt.Data.Scale),
+		CenterX:           int(mt.Data.XCenter),
+		CenterZ:           int(mt.Data.ZCenter),
+		Locked:            mt.Data.Locked,
+		TrackingPosition:  mt.Data.TrackingPosition,
+		UnlimitedTracking: mt.Data.UnlimitedTracking,
+		Colors:            mt.Data.Colors,
+	}
+
+	for _, b := range mt.Data.Banners {
+		m.Banners = append(m.Banners, MapBanner{
+			Pos:   BlockPos{X: int(b.Pos.X), Y: int(b.Pos.Y), Z: int(b.Pos.Z)},
+			Color: b.Color,
+			Name:  b.Name,
+		})
+	}
+
+	return m, nil
+}
+
+// mapIDFromName parses the ID from a map data file name such as map_3.
+func mapIDFromName(name string) (int, bool) {
+	var id int
+	if _, err := fmt.Sscanf(strings.TrimSuffix(name, ".dat"), "map_%d", &id); err != nil {
+		return 0, false
+	}
+	return id, true
+}
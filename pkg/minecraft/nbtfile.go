@@ -0,0 +1,307 @@
+package minecraft
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	mcregion "github.com/Tnze/go-mc/save/region"
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+// compression types, numbered the same as within region files
+const (
+	compressionGzip byte = 1
+	compressionZlib byte = 2
+	compressionNone byte = 3
+)
+
+// NBTFile is an NBT file within a world, or a single chunk within one of
+// its region files, read losslessly so it may be edited and written back.
+type NBTFile struct {
+	// Path is relative to the world's directory.
+	Path string
+	// Chunk is nil unless the file is a chunk within a region file.
+	Chunk *ChunkPos
+	Root  nbtree.Field
+
+	compression byte
+}
+
+func (f NBTFile) String() string {
+	if f.Chunk == nil {
+		return f.Path
+	}
+	return fmt.Sprintf("%s#%d,%d", f.Path, f.Chunk.X, f.Chunk.Z)
+}
+
+// parseNBTRef parses a reference to an NBT file relative to the world,
+// such as level.dat, or to a chunk within a region file by its chunk
+// coordinates, such as region/r.0.0.mca#3,7.
+func parseNBTRef(ref string) (string, *ChunkPos, error) {
+	path, chunkRef, hasChunk := strings.Cut(ref, "#")
+
+	path = filepath.Clean(strings.TrimPrefix(filepath.ToSlash(path), "/"))
+	if path == "." || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return "", nil, fmt.Errorf("%s is not a file within the world", ref)
+	}
+
+	if !hasChunk {
+		return path, nil, nil
+	}
+
+	xRef, zRef, ok := strings.Cut(chunkRef, ",")
+	if !ok {
+		return "", nil, fmt.Errorf("invalid chunk %s, expected x,z", chunkRef)
+	}
+
+	x, err := strconv.Atoi(strings.TrimSpace(xRef))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid chunk %s: %w", chunkRef, err)
+	}
+
+	z, err := strconv.Atoi(strings.TrimSpace(zRef))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid chunk %s: %w", chunkRef, err)
+	}
+
+	return path, &ChunkPos{X: x, Z: z}, nil
+}
+
+// ReadNBT reads the gzip, zlib or uncompressed NBT file at the given path
+// relative to the world, or a single chunk within a region file addressed
+// by its chunk coordinates such as region/r.0.0.mca#3,7.
+func (w World) ReadNBT(ref string) (*NBTFile, error) {
+	path, chunk, err := parseNBTRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := fs.ReadFile(w.fsys, filepath.Join(w.path, path))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+
+	f := &NBTFile{Path: path, Chunk: chunk}
+
+	var r io.Reader
+	if chunk == nil {
+		f.compression, r, err = detectCompression(data)
+	} else {
+		f.compression, r, err = readRegionChunk(path, data, *chunk)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", f, err)
+	}
+
+	f.Root, err = nbtree.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s NBT data: %w", f, err)
+	}
+
+	return f, nil
+}
+
+// WriteNBT writes the file back where it was read from, compressed the
+// same way it was. Level and player data keep their previous version as
+// an _old file alongside them, the same as the game does.
+func (w World) WriteNBT(f *NBTFile) error {
+	var buf bytes.Buffer
+	if err := compressTree(&buf, f.compression, f.Root); err != nil {
+		return fmt.Errorf("unable to encode %s: %w", f, err)
+	}
+
+	if w.cfg.dryRun != nil {
+		return w.reportNBTDiff(f)
+	}
+
+	original, err := fs.ReadFile(w.fsys, filepath.Join(w.path, f.Path))
+	if err != nil && (f.Chunk != nil || !errors.Is(err, fs.ErrNotExist)) {
+		return fmt.Errorf("unable to read %s: %w", f.Path, err)
+	}
+
+	data := buf.Bytes()
+	if f.Chunk != nil {
+		// the region is read again in case any other chunk has been saved
+		// since this one was read
+		data, err = writeRegionChunk(f.Path, original, *f.Chunk, append([]byte{f.compression}, data...))
+		if err != nil {
+			return fmt.Errorf("unable to write %s: %w", f, err)
+		}
+	}
+
+	var writes []fileWrite
+	if original != nil && keepsOldFile(f.Path) {
+		writes = append(writes, fileWrite{path: f.Path + "_old", data: original})
+	}
+
+	return w.writeFiles(append(writes, fileWrite{path: f.Path, data: data})...)
+}
+
+// reportNBTDiff reports the changes writing the file would make.
+func (w World) reportNBTDiff(f *NBTFile) error {
+	current, err := w.ReadNBT(f.String())
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		current = &NBTFile{Root: nbtree.Field{Node: nbtree.NewCompound(&nbtree.Compound{})}}
+	}
+
+	w.cfg.dryRun(FileDiff{File: f.String(), Changes: nbtree.Diff(current.Root.Node, f.Root.Node)})
+	return nil
+}
+
+func detectCompression(data []byte) (byte, io.Reader, error) {
+	switch {
+	case isGzip(data):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		return compressionGzip, r, err
+	case len(data) >= 2 && data[0] == 0x78:
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		return compressionZlib, r, err
+	}
+	return compressionNone, bytes.NewReader(data), nil
+}
+
+func compressTree(w io.Writer, compression byte, root nbtree.Field) error {
+	switch compression {
+	case compressionGzip:
+		gw := gzip.NewWriter(w)
+		if err := nbtree.Write(gw, root); err != nil {
+			return err
+		}
+		return gw.Close()
+	case compressionZlib:
+		zw := zlib.NewWriter(w)
+		if err := nbtree.Write(zw, root); err != nil {
+			return err
+		}
+		return zw.Close()
+	}
+	return nbtree.Write(w, root)
+}
+
+// regionSlot returns the chunk's slot within the region file at path,
+// failing if the chunk isn't within that region.
+func regionSlot(path string, chunk ChunkPos) (int, int, error) {
+	pos, err := parseRegionPos(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if rx, rz := mcregion.At(chunk.X, chunk.Z); rx != pos.X || rz != pos.Z {
+		return 0, 0, fmt.Errorf("chunk %d,%d is not within region %s", chunk.X, chunk.Z, filepath.Base(path))
+	}
+
+	x, z := mcregion.In(chunk.X, chunk.Z)
+	return x, z, nil
+}
+
+func readRegionChunk(path string, data []byte, chunk ChunkPos) (byte, io.Reader, error) {
+	x, z, err := regionSlot(path, chunk)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rg, err := mcregion.Load(&memFile{data: data})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sector, err := rg.ReadSector(x, z)
+	if err != nil {
+		if errors.Is(err, mcregion.ErrNoSector) {
+			return 0, nil, fmt.Errorf("chunk has not been generated: %w", fs.ErrNotExist)
+		}
+		return 0, nil, err
+	}
+
+	if len(sector) > 0 && sector[0]&0x80 != 0 {
+		return 0, nil, errors.New("chunks stored outside of their region file are not supported")
+	}
+
+	r, err := chunkNBTReader(sector)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return sector[0], r, nil
+}
+
+// writeRegionChunk returns the region file data with the given chunk's
+// sector replaced, updating when it was last saved.
+func writeRegionChunk(path string, data []byte, chunk ChunkPos, sector []byte) ([]byte, error) {
+	x, z, err := regionSlot(path, chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &memFile{data: data}
+	rg, err := mcregion.Load(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rg.WriteSector(x, z, sector); err != nil {
+		return nil, err
+	}
+
+	if err := rg.PadToFullSector(); err != nil {
+		return nil, err
+	}
+
+	// the timestamp is only updated by WriteSector when the chunk moves
+	binary.BigEndian.PutUint32(f.data[4096+4*(z*32+x):], uint32(time.Now().Unix()))
+
+	return f.data, nil
+}
+
+// memFile is a region file held in memory.
+type memFile struct {
+	data []byte
+	pos  int64
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.pos >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[f.pos:])
+	f.pos += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if end := f.pos + int64(len(p)); end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+	n := copy(f.data[f.pos:], p)
+	f.pos += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += int64(len(f.data))
+	}
+
+	if offset < 0 {
+		return 0, errors.New("seek before start of file")
+	}
+	f.pos = offset
+	return offset, nil
+}
@@ -0,0 +1,60 @@
+package minecraft_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/tauraamui/mcscan/internal/nbtree"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldWriteNBTReplacesOnlyTheEditedChunk(t *testing.T) {
+	fsys := mockFS{MapFS: fstest.MapFS{
+		"world/region/r.0.0.mca": &fstest.MapFile{Data: buildRegion(t,
+			testChunk{XPos: 1, ZPos: 2, Status: "features", Entities: []string{"kept"}},
+			testChunk{XPos: 3, ZPos: 4, Status: "full"},
+		)},
+	}}
+
+	world, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	f, err := world.ReadNBT("region/r.0.0.mca#1,2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path, err := nbtree.ParsePath("Status")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := nbtree.Set(f.Root.Node, path, nbtree.String("full")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := world.WriteNBT(f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for ref, want := range map[string]string{
+		"region/r.0.0.mca#1,2": `{xPos:1,yPos:0,zPos:2,Status:"full",Entities:["kept"]}`,
+		"region/r.0.0.mca#3,4": `{xPos:3,yPos:0,zPos:4,Status:"full"}`,
+	} {
+		f, err := world.ReadNBT(ref)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := nbtree.FormatSNBT(f.Root.Node, ""); got != want {
+			t.Errorf("expected %s to be %s, got %s", ref, want, got)
+		}
+	}
+
+	if _, err := world.ReadNBT("region/r.0.0.mca#33,2"); err == nil {
+		t.Error("expected an error reading a chunk outside of the region")
+	}
+}
@@ -0,0 +1,178 @@
+package minecraft
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/tauraamui/mcscan/internal/vfs"
+)
+
+type GameMode int32
+
+const (
+	Survival GameMode = iota
+	Creative
+	Adventure
+	Spectator
+)
+
+var gameModeNames = []string{"survival", "creative", "adventure", "spectator"}
+
+func (m GameMode) String() string {
+	if m < 0 || int(m) >= len(gameModeNames) {
+		return fmt.Sprintf("unknown(%d)", int32(m))
+	}
+	return gameModeNames[m]
+}
+
+func (m GameMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// ParseGameMode resolves a game mode from either its name or number.
+func ParseGameMode(s string) (GameMode, error) {
+	for i, name := range gameModeNames {
+		if strings.EqualFold(s, name) || s == fmt.Sprint(i) {
+			return GameMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown game mode %s", s)
+}
+
+// PlayerSpawn is where a player respawns, as set by sleeping in a bed or
+// using a respawn anchor.
+type PlayerSpawn struct {
+	Pos       BlockPos
+	Dimension string
+	Forced    bool
+}
+
+type Player struct {
+	UUID       string
+	Dimension  string
+	Pos        EntityPos
+	Health     float32
+	FoodLevel  int
+	XPLevel    int
+	XPProgress float32
+	XPTotal    int
+	GameMode   GameMode
+	Inventory  []Item
+	EnderChest []Item
+	// Spawn is nil unless the player has set their own spawn point.
+	Spawn *PlayerSpawn
+}
+
+type playerTag struct {
+	UUID           [4]int32
+	Dimension      string
+	Pos            []float64
+	Health         float32
+	FoodLevel      int32 `nbt:"foodLevel"`
+	XpLevel        int32
+	XpP            float32
+	XpTotal        int32
+	PlayerGameType int32 `nbt:"playerGameType"`
+	Inventory      []itemTag
+	EnderItems     []itemTag
+	SpawnX         *int32
+	SpawnY         *int32
+	SpawnZ         *int32
+	SpawnDimension string
+	SpawnForced    byte
+}
+
+func (t playerTag) player() Player {
+	p := Player{
+		UUID:       formatUUID(t.UUID),
+		Dimension:  t.Dimension,
+		Health:     t.Health,
+		FoodLevel:  int(t.FoodLevel),
+		XPLevel:    int(t.XpLevel),
+		XPProgress: t.XpP,
+		XPTotal:    int(t.XpTotal),
+		GameMode:   GameMode(t.PlayerGameType),
+		Inventory:  toItems(t.Inventory),
+		EnderChest: toItems(t.EnderItems),
+	}
+
+	if len(t.Pos) == 3 {
+		p.Pos = EntityPos{X: t.Pos[0], Y: t.Pos[1], Z: t.Pos[2]}
+	}
+
+	if t.SpawnX != nil && t.SpawnY != nil && t.SpawnZ != nil {
+		p.Spawn = &PlayerSpawn{
+			Pos:       BlockPos{X: int(*t.SpawnX), Y: int(*t.SpawnY), Z: int(*t.SpawnZ)},
+			Dimension: t.SpawnDimension,
+			Forced:    t.SpawnForced != 0,
+		}
+	}
+
+	return p
+}
+
+func (w World) playerDataPath(uuid string) string {
+	return filepath.Join(w.path, "playerdata", uuid+".dat")
+}
+
+// Players returns every player who has joined the world, sorted by UUID.
+func (w World) Players() ([]Player, error) {
+	found, err := vfs.Glob(w.fsys, filepath.Join(w.path, "playerdata", "*.dat"))
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]Player, 0, len(found))
+	for _, f := range found {
+		p, err := w.readPlayer(f)
+		if err != nil {
+			return nil, err
+		}
+		players = append(players, p)
+	}
+
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].UUID < players[j].UUID
+	})
+
+	return players, nil
+}
+
+// Player returns the player with the given UUID, the error wraps
+// fs.ErrNotExist if the player has never joined the world.
+func (w World) Player(uuid string) (Player, error) {
+	id, err := parseUUID(uuid)
+	if err != nil {
+		return Player{}, err
+	}
+	return w.readPlayer(w.playerDataPath(id))
+}
+
+func (w World) readPlayer(path string) (Player, error) {
+	fd, err := w.fsys.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Player{}, fmt.Errorf("player %s not found: %w", strings.TrimSuffix(filepath.Base(path), ".dat"), err)
+		}
+		return Player{}, fmt.Errorf("unable to open %s: %w", path, err)
+	}
+	defer fd.Close()
+
+	r, err := gzip.NewReader(fd)
+	if err != nil {
+		return Player{}, fmt.Errorf("unable to init gzip reader on %s: %w", path, err)
+	}
+
+	var pt playerTag
+	if _, err := nbt.NewDecoder(r).Decode(&pt); err != nil {
+		return Player{}, fmt.Errorf("unable to read %s NBT data: %w", path, err)
+	}
+
+	return pt.player(), nil
+}
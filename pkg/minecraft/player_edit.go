@@ -0,0 +1,183 @@
+package minecraft
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/tauraamui/mcscan/internal/nbtree"
+)
+
+// PlayerEdit modifies a player's raw data, any tag it doesn't touch is
+// written back exactly as it was read.
+type PlayerEdit func(data *nbtree.Compound) error
+
+// TeleportPlayer moves the player to the given position, and into the
+// given dimension unless it's empty.
+func TeleportPlayer(pos EntityPos, dimension string) PlayerEdit {
+	return func(data *nbtree.Compound) error {
+		data.Set("Pos", nbtree.NewList(&nbtree.List{
+			Type:  nbtree.TagDouble,
+			Items: []nbtree.Node{nbtree.Double(pos.X), nbtree.Double(pos.Y), nbtree.Double(pos.Z)},
+		}))
+
+		// otherwise the distance fallen before the player was stuck is
+		// applied as soon as they land
+		data.Set("FallDistance", nbtree.Float(0))
+
+		if len(dimension) > 0 {
+			data.Set("Dimension", nbtree.String(ParseDimensionID(dimension)))
+		}
+		return nil
+	}
+}
+
+func SetPlayerHealth(health float32) PlayerEdit {
+	return func(data *nbtree.Compound) error {
+		if health < 0 {
+			return fmt.Errorf("health must not be negative, got %v", health)
+		}
+		data.Set("Health", nbtree.Float(health))
+		return nil
+	}
+}
+
+func SetPlayerFood(level int) PlayerEdit {
+	return func(data *nbtree.Compound) error {
+		if level < 0 || level > 20 {
+			return fmt.Errorf("food level must be between 0 and 20, got %d", level)
+		}
+		data.Set("foodLevel", nbtree.Int(int32(level)))
+		return nil
+	}
+}
+
+// SetPlayerXPLevel sets the player's experience level, leaving their
+// progress towards the next level as it is.
+func SetPlayerXPLevel(level int) PlayerEdit {
+	return func(data *nbtree.Compound) error {
+		if level < 0 || level > math.MaxInt32 {
+			return fmt.Errorf("XP level must be between 0 and %d, got %d", math.MaxInt32, level)
+		}
+		data.Set("XpLevel", nbtree.Int(int32(level)))
+		return nil
+	}
+}
+
+func SetPlayerGameMode(mode GameMode) PlayerEdit {
+	return func(data *nbtree.Compound) error {
+		if mode < Survival || mode > Spectator {
+			return fmt.Errorf("unknown game mode %d", int32(mode))
+		}
+		data.Set("playerGameType", nbtree.Int(int32(mode)))
+		return nil
+	}
+}
+
+// GivePlayerItem puts the given item into the player's inventory at the
+// item's slot, replacing anything already within that slot.
+func GivePlayerItem(item Item) PlayerEdit {
+	return func(data *nbtree.Compound) error {
+		if item.Slot < math.MinInt8 || item.Slot > math.MaxInt8 {
+			return fmt.Errorf("invalid inventory slot %d", item.Slot)
+		}
+
+		if item.Count < 1 || item.Count > math.MaxInt8 {
+			return fmt.Errorf("item count must be between 1 and %d, got %d", math.MaxInt8, item.Count)
+		}
+
+		id := item.ID
+		if !strings.Contains(id, ":") {
+			id = "minecraft:" + id
+		}
+
+		inventory := playerInventory(data)
+		removeSlot(inventory, item.Slot)
+		inventory.Items = append(inventory.Items, nbtree.NewCompound(&nbtree.Compound{Fields: []nbtree.Field{
+			{Name: "Slot", Node: nbtree.Byte(int8(item.Slot))},
+			{Name: "id", Node: nbtree.String(id)},
+			{Name: "Count", Node: nbtree.Byte(int8(item.Count))},
+		}}))
+		return nil
+	}
+}
+
+// RemovePlayerItem empties the given slot of the player's inventory.
+func RemovePlayerItem(slot int) PlayerEdit {
+	return func(data *nbtree.Compound) error {
+		if !removeSlot(playerInventory(data), slot) {
+			return fmt.Errorf("inventory slot %d is already empty", slot)
+		}
+		return nil
+	}
+}
+
+func ClearPlayerInventory() PlayerEdit {
+	return func(data *nbtree.Compound) error {
+		playerInventory(data).Items = nil
+		return nil
+	}
+}
+
+func playerInventory(data *nbtree.Compound) *nbtree.List {
+	if n, ok := data.Get("Inventory"); ok {
+		if l := n.List(); l != nil {
+			// an empty list may have been saved with no item type
+			l.Type = nbtree.TagCompound
+			return l
+		}
+	}
+
+	l := &nbtree.List{Type: nbtree.TagCompound}
+	data.Set("Inventory", nbtree.NewList(l))
+	return l
+}
+
+func removeSlot(inventory *nbtree.List, slot int) bool {
+	for i, n := range inventory.Items {
+		c := n.Compound()
+		if c == nil {
+			continue
+		}
+
+		s, ok := c.Get("Slot")
+		if !ok {
+			continue
+		}
+
+		if v, ok := s.Value.(int8); ok && int(v) == slot {
+			inventory.Items = append(inventory.Items[:i], inventory.Items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// EditPlayer applies every edit to the player with the given UUID, the
+// data as it was beforehand is kept alongside as <uuid>.dat_old the
+// same way the game does.
+func (w World) EditPlayer(uuid string, edits ...PlayerEdit) error {
+	id, err := parseUUID(uuid)
+	if err != nil {
+		return err
+	}
+
+	f, err := w.ReadNBT(filepath.Join("playerdata", id+".dat"))
+	if err != nil {
+		return fmt.Errorf("unable to read player %s: %w", uuid, err)
+	}
+
+	data := f.Root.Compound()
+	if data == nil {
+		return fmt.Errorf("player %s data is not a compound", uuid)
+	}
+
+	for _, edit := range edits {
+		if err := edit(data); err != nil {
+			return err
+		}
+	}
+
+	return w.WriteNBT(f)
+}
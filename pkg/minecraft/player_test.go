@@ -0,0 +1,211 @@
+package minecraft_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Tnze/go-mc/nbt"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func gzipNBT(t *testing.T, v any) []byte {
+	t.Helper()
+
+	data, err := nbt.Marshal(v)
+	if err != nil {
+		t.Fatalf("unable to encode NBT: %v", err)
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		t.Fatalf("unable to compress NBT: %v", err)
+	}
+	gw.Close()
+
+	return buf.Bytes()
+}
+
+func TestWorldPlayersReadsPlayerData(t *testing.T) {
+	type item struct {
+		ID    string `nbt:"id"`
+		Count byte
+		Slot  byte
+	}
+
+	type player struct {
+		UUID           [4]int32
+		Dimension      string
+		Pos            []float64
+		Health         float32
+		XpLevel        int32
+		PlayerGameType int32 `nbt:"playerGameType"`
+		Inventory      []item
+		EnderItems     []item
+		SpawnX         int32
+		SpawnY         int32
+		SpawnZ         int32
+		SpawnDimension string
+	}
+
+	fsys := fstest.MapFS{
+		"world/playerdata/00000001-0000-0002-0000-000300000004.dat": &fstest.MapFile{Data: gzipNBT(t, player{
+			UUID:           [4]int32{1, 2, 3, 4},
+			Dimension:      mc.Nether,
+			Pos:            []float64{1.5, 64, -2.5},
+			Health:         18,
+			XpLevel:        30,
+			PlayerGameType: 1,
+			Inventory:      []item{{ID: "minecraft:diamond_pickaxe", Count: 1, Slot: 0}},
+			EnderItems:     []item{{ID: "minecraft:elytra", Count: 1, Slot: 4}},
+			SpawnX:         10, SpawnY: 70, SpawnZ: -30,
+			SpawnDimension: mc.Overworld,
+		})},
+		// backups kept by the game are not players
+		"world/playerdata/00000001-0000-0002-0000-000300000004.dat_old": &fstest.MapFile{},
+	}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	players, err := world.Players()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(players) != 1 {
+		t.Fatalf("expected 1 player, got %d", len(players))
+	}
+
+	p := players[0]
+	if p.UUID != "00000001-0000-0002-0000-000300000004" || p.Dimension != mc.Nether || p.GameMode != mc.Creative {
+		t.Errorf("unexpected player %+v", p)
+	}
+
+	if len(p.Inventory) != 1 || p.Inventory[0].ID != "minecraft:diamond_pickaxe" {
+		t.Errorf("unexpected inventory %+v", p.Inventory)
+	}
+
+	if len(p.EnderChest) != 1 || p.EnderChest[0].Slot != 4 {
+		t.Errorf("unexpected ender chest %+v", p.EnderChest)
+	}
+
+	if p.Spawn == nil || p.Spawn.Pos != (mc.BlockPos{X: 10, Y: 70, Z: -30}) {
+		t.Errorf("unexpected spawn %+v", p.Spawn)
+	}
+
+	if _, err := world.Player("00000009-0000-0000-0000-000000000000"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected missing player to be not exist, got %v", err)
+	}
+
+	// UUIDs without hyphens are normalised
+	if p, err := world.Player("00000001000000020000000300000004"); err != nil || p.UUID != "00000001-0000-0002-0000-000300000004" {
+		t.Errorf("expected player by UUID without hyphens, got %+v: %v", p, err)
+	}
+
+	fsys["world/stats/x.json"] = &fstest.MapFile{Data: []byte(`{"stats": {}}`)}
+	for _, id := range []string{"../stats/x", "00000001-0000-0002-0000-00030000000", "0000000-10000-0002-0000-000300000004"} {
+		if _, err := world.Player(id); err == nil || errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected %q to be rejected as a UUID, got %v", id, err)
+		}
+
+		if _, err := world.PlayerStats(id); err == nil || errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected %q to be rejected as a UUID, got %v", id, err)
+		}
+
+		if _, err := world.PlayerAdvancements(id); err == nil || errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected %q to be rejected as a UUID, got %v", id, err)
+		}
+	}
+}
+
+func TestWorldEditPlayerKeepsUntouchedDataAndBackup(t *testing.T) {
+	type item struct {
+		ID    string `nbt:"id"`
+		Count byte
+		Slot  byte
+	}
+
+	type player struct {
+		UUID      [4]int32
+		Dimension string
+		Pos       []float64
+		Health    float32
+		Inventory []item
+		Unknown   string `nbt:"mod:data"`
+	}
+
+	path := "world/playerdata/00000001-0000-0002-0000-000300000004.dat"
+	original := gzipNBT(t, player{
+		UUID:      [4]int32{1, 2, 3, 4},
+		Dimension: mc.Nether,
+		Pos:       []float64{1.5, 64, -2.5},
+		Health:    2,
+		Inventory: []item{
+			{ID: "minecraft:dirt", Count: 64, Slot: 0},
+			{ID: "minecraft:stone", Count: 64, Slot: 1},
+		},
+		Unknown: "kept",
+	})
+	fsys := mockFS{MapFS: fstest.MapFS{path: &fstest.MapFile{Data: original}}}
+
+	world, err := mc.OpenWorld(fsys, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	err = world.EditPlayer(
+		"00000001-0000-0002-0000-000300000004",
+		mc.TeleportPlayer(mc.EntityPos{X: 0.5, Y: 80, Z: 0.5}, "overworld"),
+		mc.SetPlayerHealth(20),
+		mc.SetPlayerGameMode(mc.Spectator),
+		mc.RemovePlayerItem(0),
+		mc.GivePlayerItem(mc.Item{ID: "torch", Count: 16, Slot: 1}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(fsys.MapFS[path+"_old"].Data, original) {
+		t.Error("expected the original data to be kept as .dat_old")
+	}
+
+	p, err := world.Player("00000001-0000-0002-0000-000300000004")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Dimension != mc.Overworld || p.Pos != (mc.EntityPos{X: 0.5, Y: 80, Z: 0.5}) || p.Health != 20 || p.GameMode != mc.Spectator {
+		t.Errorf("unexpected player %+v", p)
+	}
+
+	if len(p.Inventory) != 1 || p.Inventory[0].ID != "minecraft:torch" || p.Inventory[0].Count != 16 || p.Inventory[0].Slot != 1 {
+		t.Errorf("unexpected inventory %+v", p.Inventory)
+	}
+
+	var edited player
+	r, err := gzip.NewReader(bytes.NewReader(fsys.MapFS[path].Data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := nbt.NewDecoder(r).Decode(&edited); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if edited.Unknown != "kept" {
+		t.Errorf("expected unknown tags to be kept, got %q", edited.Unknown)
+	}
+
+	if err := world.EditPlayer("00000001-0000-0002-0000-000300000004", mc.RemovePlayerItem(0)); err == nil {
+		t.Error("expected an error removing an empty slot")
+	}
+}
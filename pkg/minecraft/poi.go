@@ -0,0 +1,118 @@
+package minecraft
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// POI is a point of interest tracked by the game, such as a villager's
+// workstation, a bed, a bell, a nether portal or a lodestone.
+type POI struct {
+	Type  string
+	Pos   BlockPos
+	Chunk ChunkPos
+	// FreeTickets is how many more villagers may claim the POI.
+	FreeTickets int
+}
+
+// CountPOIs returns the total of each POI type across the given POIs.
+func CountPOIs(pois []POI) map[string]uint64 {
+	counts := map[string]uint64{}
+	for _, p := range pois {
+		counts[p.Type]++
+	}
+	return counts
+}
+
+type poiChunkTag struct {
+	// sections are keyed by their Y index as a string
+	Sections map[string]struct {
+		Valid   byte
+		Records []poiRecordTag
+	}
+}
+
+type poiRecordTag struct {
+	Type        string  `nbt:"type"`
+	Pos         []int32 `nbt:"pos"`
+	FreeTickets int32   `nbt:"free_tickets"`
+}
+
+// appendChunkPOIs decodes the given POI chunk, appending every record
+// within area if given.
+func appendChunkPOIs(pois []POI, cd chunkData, area *Area) ([]POI, error) {
+	r, err := chunkNBTReader(cd.data)
+	if err != nil {
+		return nil, err
+	}
+
+	var pc poiChunkTag
+	if _, err := nbt.NewDecoder(r).Decode(&pc); err != nil {
+		return nil, fmt.Errorf("unable to load POI NBT data: %w", err)
+	}
+
+	for _, section := range pc.Sections {
+		for _, rec := range section.Records {
+			if len(rec.Pos) != 3 {
+				return nil, fmt.Errorf("POI %s has invalid position %v", rec.Type, rec.Pos)
+			}
+
+			pos := BlockPos{X: int(rec.Pos[0]), Y: int(rec.Pos[1]), Z: int(rec.Pos[2])}
+			if area != nil && !area.Contains(pos) {
+				continue
+			}
+
+			pois = append(pois, POI{
+				Type:        rec.Type,
+				Pos:         pos,
+				Chunk:       cd.pos,
+				FreeTickets: int(rec.FreeTickets),
+			})
+		}
+	}
+
+	return pois, nil
+}
+
+// POIs returns every point of interest stored within the world's poi
+// region files, keyed by the ID of the dimension they were found in.
+func (w World) POIs(opts ...ScanOption) (map[string][]POI, error) {
+	cfg := resolveScanConfig(opts)
+	dims, err := w.selectDimensions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string][]POI{}
+	for _, dim := range dims {
+		workerPOIs := []*[]POI{}
+		err := w.scanChunks(cfg, dim.poiRegions, func() chunkWorker {
+			pois := []POI{}
+			workerPOIs = append(workerPOIs, &pois)
+			return func(cd chunkData) (err error) {
+				pois, err = appendChunkPOIs(pois, cd, cfg.area)
+				return err
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		pois := []POI{}
+		for _, wp := range workerPOIs {
+			pois = append(pois, *wp...)
+		}
+
+		sort.Slice(pois, func(i, j int) bool {
+			return lessBlock(
+				Block{ID: pois[i].Type, Pos: pois[i].Pos},
+				Block{ID: pois[j].Type, Pos: pois[j].Pos},
+			)
+		})
+		found[dim.ID] = pois
+	}
+
+	return found, nil
+}
@@ -0,0 +1,67 @@
+package minecraft_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldPOIsReadsRecordsAcrossSections(t *testing.T) {
+	type record struct {
+		Type        string  `nbt:"type"`
+		Pos         []int32 `nbt:"pos"`
+		FreeTickets int32   `nbt:"free_tickets"`
+	}
+
+	type section struct {
+		Valid   byte
+		Records []record
+	}
+
+	fsys := fstest.MapFS{
+		"world/poi/r.0.0.mca": &fstest.MapFile{Data: buildRegion(t, testChunk{
+			XPos: 1, ZPos: 2,
+			Sections: map[string]section{
+				"4": {Valid: 1, Records: []record{
+					{Type: "minecraft:nether_portal", Pos: []int32{20, 70, 40}},
+					{Type: "minecraft:nether_portal", Pos: []int32{20, 71, 40}},
+				}},
+				"-1": {Valid: 1, Records: []record{
+					{Type: "minecraft:farmer", Pos: []int32{18, -5, 33}, FreeTickets: 1},
+				}},
+			},
+		})},
+		// the game creates region files before saving any chunks to them
+		"world/poi/r.0.1.mca": &fstest.MapFile{},
+	}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	found, err := world.POIs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pois := found[mc.Overworld]
+	if len(pois) != 3 {
+		t.Fatalf("expected 3 POIs, got %d", len(pois))
+	}
+
+	farmer := pois[0]
+	if farmer.Type != "minecraft:farmer" || farmer.Pos != (mc.BlockPos{X: 18, Y: -5, Z: 33}) || farmer.FreeTickets != 1 {
+		t.Errorf("unexpected POI %+v", farmer)
+	}
+
+	if farmer.Chunk != (mc.ChunkPos{X: 1, Z: 2}) {
+		t.Errorf("unexpected chunk %+v", farmer.Chunk)
+	}
+
+	if n := mc.CountPOIs(pois)["minecraft:nether_portal"]; n != 2 {
+		t.Errorf("expected 2 nether portals, got %d", n)
+	}
+}
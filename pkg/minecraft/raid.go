@@ -0,0 +1,86 @@
+package minecraft
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Raid struct {
+	ID     int
+	Status string
+	// Center is the village centre the raid is attacking.
+	Center        BlockPos
+	Started       bool
+	Active        bool
+	BadOmenLevel  int
+	GroupsSpawned int
+	NumGroups     int
+	TicksActive   int64
+	// Heroes are the UUIDs of every player who has fought in the raid.
+	Heroes []string
+}
+
+type raidsTag struct {
+	Data struct {
+		Raids []struct {
+			ID                 int32 `nbt:"Id"`
+			Status             string
+			CX, CY, CZ         int32
+			Started            bool
+			Active             bool
+			BadOmenLevel       int32
+			GroupsSpawned      int32
+			NumGroups          int32
+			TicksActive        int64
+			HeroesOfTheVillage [][4]int32
+		}
+	} `nbt:"data"`
+}
+
+// raidsFileName returns the name of the data file a dimension keeps its
+// raids within, the end's differs from every other dimension.
+func raidsFileName(dimension string) string {
+	if ParseDimensionID(dimension) == End {
+		return "raids_end"
+	}
+	return "raids"
+}
+
+// Raids returns every raid in progress within the given dimension, an
+// empty dimension is the overworld.
+func (w World) Raids(dimension string) ([]Raid, error) {
+	if len(dimension) == 0 {
+		dimension = Overworld
+	}
+
+	var rt raidsTag
+	if err := w.decodeDataFile(raidsFileName(dimension), dimension, &rt); err != nil {
+		return nil, fmt.Errorf("unable to read raids: %w", err)
+	}
+
+	raids := make([]Raid, 0, len(rt.Data.Raids))
+	for _, r := range rt.Data.Raids {
+		raid := Raid{
+			ID:            int(r.ID),
+			Status:        r.Status,
+			Center:        BlockPos{X: int(r.CX), Y: int(r.CY), Z: int(r.CZ)},
+			Started:       r.Started,
+			Active:        r.Active,
+			BadOmenLevel:  int(r.BadOmenLevel),
+			GroupsSpawned: int(r.GroupsSpawned),
+			NumGroups:     int(r.NumGroups),
+			TicksActive:   r.TicksActive,
+		}
+
+		for _, h := range r.HeroesOfTheVillage {
+			raid.Heroes = append(raid.Heroes, formatUUID(h))
+		}
+		raids = append(raids, raid)
+	}
+
+	sort.Slice(raids, func(i, j int) bool {
+		return raids[i].ID < raids[j].ID
+	})
+
+	return raids, nil
+}
@@ -1,11 +1,14 @@
 package minecraft
 
 import (
-	"encoding/json"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
 	"fmt"
-	"sync"
-
-	stdos "os"
+	"io"
+	"path/filepath"
 
 	"github.com/Tnze/go-mc/level"
 	"github.com/Tnze/go-mc/level/block"
@@ -18,99 +21,301 @@ type Region interface {
 	Close() error
 }
 
+// RegionPos is the position of a region within its dimension, as
+// encoded within the region's file name r.<x>.<z>.mca.
+type RegionPos struct {
+	X, Z int
+}
+
+func parseRegionPos(path string) (RegionPos, error) {
+	pos := RegionPos{}
+	name := filepath.Base(path)
+	if _, err := fmt.Sscanf(name, "r.%d.%d.mca", &pos.X, &pos.Z); err != nil {
+		return pos, fmt.Errorf("unable to parse region position from %s: %w", path, err)
+	}
+
+	// Sscanf ignores anything trailing, such as r.0.0.mca.bak.mca
+	if name != fmt.Sprintf("r.%d.%d.mca", pos.X, pos.Z) {
+		return pos, fmt.Errorf("unable to parse region position from %s", path)
+	}
+	return pos, nil
+}
+
+type ChunkPos struct {
+	X, Z int
+}
+
+type BlockPos struct {
+	X, Y, Z int
+}
+
 type Block struct {
-	ID string
+	ID    string
+	Pos   BlockPos
+	Chunk ChunkPos
+}
+
+// ChunkCorruptError is returned for any chunk which could not be read from
+// its region file, or which could not be decoded. Should the region's header
+// be unreadable, none of its chunks can be found and WholeRegion is set
+// instead of Chunk.
+type ChunkCorruptError struct {
+	Region      string
+	Chunk       ChunkPos
+	WholeRegion bool
+	Err         error
+}
+
+func (e *ChunkCorruptError) Error() string {
+	if e.WholeRegion {
+		return fmt.Sprintf("region %s is corrupt: %v", e.Region, e.Err)
+	}
+	return fmt.Sprintf("chunk %d,%d in region %s is corrupt: %v", e.Chunk.X, e.Chunk.Z, e.Region, e.Err)
 }
 
-func ReadRegionsBlocks(r Region, c chan<- Block) {
-	// chestEntity := block.ChestEntity{}
-	// chestID := block.EntityTypes[chestEntity.ID()]
-	defer r.Close()
+func (e *ChunkCorruptError) Unwrap() error {
+	return e.Err
+}
+
+// chunkData is the raw, still compressed, data of a single chunk as read
+// from its region file.
+type chunkData struct {
+	region string
+	pos    ChunkPos
+	data   []byte
+}
+
+// readRegionChunks reads the data of every chunk within the region at path
+// and the configured area, sending them on to out in batches. Each chunk
+// which cannot be read is passed to onCorrupt, returning nil skips over the
+// chunk whereas returning an error stops reading the region and is returned.
+func readRegionChunks(
+	ctx context.Context, cfg scanConfig, r Region, path string, pos RegionPos,
+	out chan<- []chunkData, onCorrupt func(err *ChunkCorruptError) error,
+) error {
+	batchSize := cfg.batchSize
+	batch := make([]chunkData, 0, batchSize)
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
 
-	// TODO(tauraamui): re-write all of this
+		select {
+		case out <- batch:
+			batch = make([]chunkData, 0, batchSize)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 
-	wg := sync.WaitGroup{}
 	for i := 0; i < 32; i++ {
 		for j := 0; j < 32; j++ {
 			if !r.ExistSector(i, j) {
 				continue
 			}
 
-			data := must(r.ReadSector(i, j))
-
-			wg.Add(1)
-			go func(wg *sync.WaitGroup, data []byte) {
-				defer wg.Done()
-				/*
-					for i := 0; i < len(lc.BlockEntity); i++ {
-						be := lc.BlockEntity[i]
-						if chestID == be.Type {
-							beTagData := blockEntityTag{}
-							be.Data.Unmarshal(&beTagData)
-							if len(beTagData.Items) > 0 {
-								fmt.Printf("player placed chest items: %+v\n", beTagData.Items)
-							}
-						}
-					}
-				*/
-
-				var sc save.Chunk
-				sc.Load(data)
-
-				lc := must(level.ChunkFromSave(&sc))
-
-				for i := 0; i < len(lc.BlockEntity); i++ {
-					be := lc.BlockEntity[i]
-					c <- Block{ID: block.EntityList[be.Type].ID()}
-				}
+			chunk := ChunkPos{X: pos.X*32 + i, Z: pos.Z*32 + j}
+			if cfg.area != nil && !cfg.area.intersectsChunk(chunk) {
+				continue
+			}
 
-				count := len(lc.Sections)
+			if cfg.onlyChunks != nil && !cfg.onlyChunks(pos, chunk) {
+				continue
+			}
 
-				if count == 0 {
-					return
+			data, err := r.ReadSector(i, j)
+			if err != nil {
+				if err := onCorrupt(&ChunkCorruptError{Region: path, Chunk: chunk, Err: err}); err != nil {
+					return err
 				}
+				continue
+			}
+
+			batch = append(batch, chunkData{region: path, pos: chunk, data: data})
+			if len(batch) < batchSize {
+				continue
+			}
 
-				for i := 0; i < count; i++ {
-					sec := lc.Sections[i]
-					blockCount := int(sec.BlockCount)
-					if blockCount == 0 {
-						continue
-					}
+			if err := send(); err != nil {
+				return err
+			}
+		}
+	}
 
-					for j := 0; j < blockCount; j++ {
-						b := block.StateList[sec.GetBlock(j)]
+	return send()
+}
 
-						if block.IsAirBlock(b) {
-							continue
-						}
+type decodedChunk struct {
+	pos   ChunkPos
+	saved *save.Chunk
+	// converted is only populated once the chunk's sections are first needed,
+	// as converting them is far more expensive than decoding the chunk's NBT
+	converted *level.Chunk
+}
 
-						c <- Block{ID: b.ID()}
-					}
-				}
-			}(&wg, data)
+// chunkNBTReader returns a reader of the chunk's NBT data, decompressing
+// it as declared by the data's leading compression type.
+func chunkNBTReader(data []byte) (io.Reader, error) {
+	if len(data) == 0 {
+		return nil, errors.New("chunk data is empty")
+	}
+
+	r := bytes.NewReader(data[1:])
+	switch data[0] {
+	case 1:
+		return gzip.NewReader(r)
+	case 2:
+		return zlib.NewReader(r)
+	case 3:
+		return r, nil
+	}
+
+	return nil, fmt.Errorf("unknown compression type %d", data[0])
+}
+
+func decodeChunk(cd chunkData) (*decodedChunk, error) {
+	if len(cd.data) == 0 {
+		return nil, errors.New("chunk data is empty")
+	}
+
+	var sc save.Chunk
+	if err := sc.Load(cd.data); err != nil {
+		return nil, fmt.Errorf("unable to load chunk NBT data: %w", err)
+	}
+
+	return &decodedChunk{pos: cd.pos, saved: &sc}, nil
+}
+
+func (c *decodedChunk) sections() ([]level.Section, error) {
+	if c.converted == nil {
+		lc, err := level.ChunkFromSave(c.saved)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert chunk from save data: %w", err)
 		}
+		c.converted = lc
 	}
 
-	wg.Wait()
+	return c.converted.Sections, nil
 }
 
-type blockEntityTag struct {
-	Items []save.Item
+// minSectionY returns the Y position of the chunk's lowest section.
+func (c *decodedChunk) minSectionY() int {
+	return int(c.saved.YPos)
 }
 
-func (b *blockEntityTag) unmarshal(d any) error {
-	data, err := json.Marshal(d)
+// countStates increments the count of each block state within the chunk,
+// and area if given, counts is indexed by state ID and includes air.
+func (c *decodedChunk) countStates(counts []uint64, area *Area) error {
+	sections, err := c.sections()
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(data, b)
+	for i := 0; i < len(sections); i++ {
+		sec := sections[i]
+		if sec.BlockCount == 0 {
+			continue
+		}
+
+		sectionY := i + c.minSectionY()
+		switch c.sectionWithin(area, sectionY) {
+		case outsideArea:
+			continue
+		case insideArea:
+			for j := 0; j < 16*16*16; j++ {
+				counts[sec.GetBlock(j)]++
+			}
+		case overlapsArea:
+			for j := 0; j < 16*16*16; j++ {
+				if area.Contains(sectionBlockPos(c.pos, sectionY, j)) {
+					counts[sec.GetBlock(j)]++
+				}
+			}
+		}
+	}
+
+	return nil
 }
 
-func must[T any](v T, err error) T {
+// appendBlocks appends every block within the chunk, and area if given,
+// whose state is wanted, wanted is indexed by state ID.
+//
+// Block entities are skipped as they share their position, and so would
+// be found twice, with the block state they're attached to.
+func (c *decodedChunk) appendBlocks(blocks []Block, wanted []bool, area *Area) ([]Block, error) {
+	sections, err := c.sections()
 	if err != nil {
-		_, _ = fmt.Fprintln(stdos.Stderr, err)
-		stdos.Exit(1)
+		return nil, err
+	}
+
+	for i := 0; i < len(sections); i++ {
+		sec := sections[i]
+		if sec.BlockCount == 0 {
+			continue
+		}
+
+		sectionY := i + c.minSectionY()
+		within := c.sectionWithin(area, sectionY)
+		if within == outsideArea {
+			continue
+		}
+
+		for j := 0; j < 16*16*16; j++ {
+			state := sec.GetBlock(j)
+			if !wanted[state] {
+				continue
+			}
+
+			pos := sectionBlockPos(c.pos, sectionY, j)
+			if within == overlapsArea && !area.Contains(pos) {
+				continue
+			}
+
+			blocks = append(blocks, Block{
+				ID:    block.StateList[state].ID(),
+				Pos:   pos,
+				Chunk: c.pos,
+			})
+		}
+	}
+
+	return blocks, nil
+}
+
+type areaOverlap int
+
+const (
+	outsideArea areaOverlap = iota
+	insideArea
+	overlapsArea
+)
+
+// sectionWithin reports how much of the section at sectionY is within
+// area, a nil area contains everything.
+func (c *decodedChunk) sectionWithin(area *Area, sectionY int) areaOverlap {
+	if area == nil {
+		return insideArea
+	}
+
+	minY, maxY := sectionY*16, sectionY*16+15
+	if !area.intersectsY(minY, maxY) || !area.intersectsChunk(c.pos) {
+		return outsideArea
+	}
+
+	if area.coversY(minY, maxY) && area.coversChunk(c.pos) {
+		return insideArea
+	}
+
+	return overlapsArea
+}
+
+// sectionBlockPos converts the index of a block within a chunk section,
+// ordered as YZX, into an absolute block position.
+func sectionBlockPos(chunk ChunkPos, sectionY, i int) BlockPos {
+	return BlockPos{
+		X: chunk.X*16 + i&15,
+		Y: sectionY*16 + i>>8,
+		Z: chunk.Z*16 + (i>>4)&15,
 	}
-	return v
 }
@@ -0,0 +1,51 @@
+package minecraft_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+	mcregion "github.com/Tnze/go-mc/save/region"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+// testChunk holds only what's needed of a chunk to be decoded.
+type testChunk struct {
+	XPos          int32  `nbt:"xPos"`
+	YPos          int32  `nbt:"yPos"`
+	ZPos          int32  `nbt:"zPos"`
+	Status        string `nbt:"Status"`
+	BlockEntities any    `nbt:"block_entities,omitempty"`
+	Entities      any    `nbt:"Entities,omitempty"`
+	Sections      any    `nbt:"Sections,omitempty"`
+}
+
+// buildRegion returns a region file's data holding the given chunks,
+// each placed in the slot matching its position.
+func buildRegion(t *testing.T, chunks ...testChunk) []byte {
+	t.Helper()
+
+	f := &mc.MemFile{}
+	r, err := mcregion.CreateWriter(f)
+	if err != nil {
+		t.Fatalf("unable to create region: %v", err)
+	}
+
+	for _, c := range chunks {
+		var buf bytes.Buffer
+		buf.WriteByte(2)
+		zw := zlib.NewWriter(&buf)
+		if err := nbt.NewEncoder(zw).Encode(c, ""); err != nil {
+			t.Fatalf("unable to encode chunk: %v", err)
+		}
+		zw.Close()
+
+		x, z := mcregion.In(int(c.XPos), int(c.ZPos))
+		if err := r.WriteSector(x, z, buf.Bytes()); err != nil {
+			t.Fatalf("unable to write chunk: %v", err)
+		}
+	}
+
+	return f.Data()
+}
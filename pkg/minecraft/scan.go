@@ -0,0 +1,270 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	mcregion "github.com/Tnze/go-mc/save/region"
+)
+
+const (
+	defaultBatchSize      = 16
+	defaultMaxOpenRegions = 8
+)
+
+type scanConfig struct {
+	dimensions     []string
+	onCorrupt      func(err *ChunkCorruptError) error
+	workers        int
+	batchSize      int
+	maxOpenRegions int
+	area           *Area
+	countCache     BlocksCountCache
+	// onlyChunks restricts which chunks are read, if set.
+	onlyChunks func(region RegionPos, chunk ChunkPos) bool
+}
+
+type ScanOption func(cfg *scanConfig)
+
+// InDimensions restricts a scan to only the dimensions with the given IDs,
+// by default every dimension found within the world is scanned.
+func InDimensions(ids ...string) ScanOption {
+	return func(cfg *scanConfig) {
+		cfg.dimensions = append(cfg.dimensions, ids...)
+	}
+}
+
+// SkipCorruptChunks makes a scan skip over any chunk which cannot be read
+// or decoded, or region whose header cannot be read, passing each to
+// report, rather than aborting the scan.
+func SkipCorruptChunks(report func(err *ChunkCorruptError)) ScanOption {
+	return func(cfg *scanConfig) {
+		cfg.onCorrupt = func(err *ChunkCorruptError) error {
+			if report != nil {
+				report(err)
+			}
+			return nil
+		}
+	}
+}
+
+// WithWorkers sets how many chunks may be decoded at once, by default
+// this is the number of available CPUs.
+func WithWorkers(n int) ScanOption {
+	return func(cfg *scanConfig) {
+		if n > 0 {
+			cfg.workers = n
+		}
+	}
+}
+
+// WithBatchSize sets how many chunks are handed to a worker at a time.
+func WithBatchSize(n int) ScanOption {
+	return func(cfg *scanConfig) {
+		if n > 0 {
+			cfg.batchSize = n
+		}
+	}
+}
+
+// WithMaxOpenRegions sets how many region files may be open and read from
+// at once, regions are only opened once there's capacity to read them.
+func WithMaxOpenRegions(n int) ScanOption {
+	return func(cfg *scanConfig) {
+		if n > 0 {
+			cfg.maxOpenRegions = n
+		}
+	}
+}
+
+// WithinArea restricts a scan to only the blocks within the given area,
+// any region or chunk entirely outside of it is skipped without being read.
+func WithinArea(area Area) ScanOption {
+	return func(cfg *scanConfig) {
+		cfg.area = &area
+	}
+}
+
+func resolveScanConfig(opts []ScanOption) scanConfig {
+	cfg := scanConfig{
+		workers:        runtime.NumCPU(),
+		batchSize:      defaultBatchSize,
+		maxOpenRegions: defaultMaxOpenRegions,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+func (w World) selectDimensions(cfg scanConfig) ([]Dimension, error) {
+	if len(cfg.dimensions) == 0 {
+		return w.dimensions, nil
+	}
+
+	selected := make([]Dimension, 0, len(cfg.dimensions))
+	for _, id := range cfg.dimensions {
+		dim, ok := w.Dimension(ParseDimensionID(id))
+		if !ok {
+			return nil, fmt.Errorf("dimension %s not found in world %s", id, w.name)
+		}
+		selected = append(selected, dim)
+	}
+
+	return selected, nil
+}
+
+// chunkWorker is handed every chunk read by a single scan worker, so it
+// may accumulate results without any synchronisation. Any error returned
+// is treated as the chunk being corrupt.
+type chunkWorker func(cd chunkData) error
+
+// terrainWorker returns a chunkWorker which decodes each chunk's terrain
+// before handing it to fn.
+func terrainWorker(fn func(c *decodedChunk) error) chunkWorker {
+	return func(cd chunkData) error {
+		chunk, err := decodeChunk(cd)
+		if err != nil {
+			return err
+		}
+		return fn(chunk)
+	}
+}
+
+// scanChunks hands every chunk within the given regions to a pool of
+// workers, each worker is created up front by calling newWorker.
+func (w World) scanChunks(cfg scanConfig, regions []region, newWorker func() chunkWorker) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	abort := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	// chunks are read and decoded concurrently, so the configured handler
+	// is serialised to save every caller from having to synchronise
+	onCorrupt := func(err *ChunkCorruptError) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if cfg.onCorrupt == nil {
+			return err
+		}
+		return cfg.onCorrupt(err)
+	}
+
+	batches := make(chan []chunkData, cfg.workers)
+
+	pending := make(chan region)
+	go func() {
+		defer close(pending)
+		for _, rref := range regions {
+			if cfg.area != nil && !cfg.area.intersectsRegion(rref.pos) {
+				continue
+			}
+
+			select {
+			case pending <- rref:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	readers := sync.WaitGroup{}
+	for i := 0; i < cfg.maxOpenRegions; i++ {
+		readers.Add(1)
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			for rref := range pending {
+				if err := w.readRegion(ctx, cfg, rref, batches, onCorrupt); err != nil {
+					abort(err)
+				}
+			}
+		}(&readers)
+	}
+
+	go func(wg *sync.WaitGroup) {
+		defer close(batches)
+		wg.Wait()
+	}(&readers)
+
+	chunkWorkers := make([]chunkWorker, cfg.workers)
+	for i := range chunkWorkers {
+		chunkWorkers[i] = newWorker()
+	}
+
+	workers := sync.WaitGroup{}
+	for i := range chunkWorkers {
+		workers.Add(1)
+		go func(wg *sync.WaitGroup, work chunkWorker) {
+			defer wg.Done()
+			for batch := range batches {
+				// keep draining once aborted so no reader is left blocked
+				if ctx.Err() != nil {
+					continue
+				}
+
+				for _, cd := range batch {
+					err := work(cd)
+					if err == nil {
+						continue
+					}
+
+					if err := onCorrupt(&ChunkCorruptError{Region: cd.region, Chunk: cd.pos, Err: err}); err != nil {
+						abort(err)
+						break
+					}
+				}
+			}
+		}(&workers, chunkWorkers[i])
+	}
+
+	workers.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return firstErr
+}
+
+// readRegion opens the given region, reading all of its chunks before
+// closing it again.
+func (w World) readRegion(
+	ctx context.Context, cfg scanConfig, rref region,
+	out chan<- []chunkData, onCorrupt func(err *ChunkCorruptError) error,
+) error {
+	fd, err := w.regionFDs.open(w.fsys, rref.path)
+	if err != nil {
+		return fmt.Errorf("unable to open region %s: %w", rref.path, err)
+	}
+	defer w.regionFDs.close(fd)
+	rref.fd = fd
+
+	// the game creates region files before saving any chunks into them
+	fi, err := fd.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to stat region %s: %w", rref.path, err)
+	}
+
+	if fi.Size() == 0 {
+		return nil
+	}
+
+	loadedRegion, err := mcregion.Load(&rref)
+	if err != nil {
+		return onCorrupt(&ChunkCorruptError{Region: rref.path, WholeRegion: true, Err: err})
+	}
+
+	return readRegionChunks(ctx, cfg, loadedRegion, rref.path, rref.pos, out, onCorrupt)
+}
@@ -0,0 +1,135 @@
+package minecraft_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"testing"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+// dirFS exposes a directory on disk as a read only filesystem.FS.
+type dirFS struct {
+	fs.FS
+}
+
+func (d dirFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(d.FS, name)
+}
+
+func (d dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(d.FS, name)
+}
+
+func (d dirFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return errors.New("dirFS is read only")
+}
+
+func (d dirFS) MkdirAll(path string, perm fs.FileMode) error {
+	return errors.New("dirFS is read only")
+}
+
+func (d dirFS) OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		return nil, errors.New("dirFS is read only")
+	}
+	return d.Open(name)
+}
+
+func (d dirFS) Rename(oldname, newname string) error {
+	return errors.New("dirFS is read only")
+}
+
+func (d dirFS) Remove(name string) error {
+	return errors.New("dirFS is read only")
+}
+
+func openTestdataWorld(tb testing.TB) *mc.World {
+	tb.Helper()
+
+	world, err := mc.OpenWorld(dirFS{os.DirFS("../../testdata")}, ".")
+	if err != nil {
+		tb.Fatalf("unable to open testdata world: %v", err)
+	}
+
+	return world
+}
+
+func TestWorldFindBlocksReturnsAbsolutePositions(t *testing.T) {
+	world := openTestdataWorld(t)
+	defer world.Close()
+
+	found, err := world.FindBlocks([]string{"minecraft:spawner"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(found[mc.Overworld]) != 8 {
+		t.Fatalf("expected 8 spawners in the overworld, got %d", len(found[mc.Overworld]))
+	}
+
+	// both are within r.-1.-1.mca, below Y 0, with positions matching
+	// their spawner block entities
+	expected := []mc.Block{
+		{ID: "minecraft:spawner", Pos: mc.BlockPos{X: -15, Y: -14, Z: -96}, Chunk: mc.ChunkPos{X: -1, Z: -6}},
+		{ID: "minecraft:spawner", Pos: mc.BlockPos{X: -204, Y: -31, Z: -238}, Chunk: mc.ChunkPos{X: -13, Z: -15}},
+	}
+
+	for _, e := range expected {
+		var ok bool
+		for _, b := range found[mc.Overworld] {
+			ok = ok || b == e
+		}
+
+		if !ok {
+			t.Errorf("expected to find %+v, found %+v", e, found[mc.Overworld])
+		}
+	}
+}
+
+// benchWorkerCounts returns the worker pool sizes to benchmark, skipping
+// any which would repeat on machines with few CPUs.
+func benchWorkerCounts() []int {
+	counts := []int{1}
+	for _, n := range []int{4, runtime.NumCPU()} {
+		if n > counts[len(counts)-1] {
+			counts = append(counts, n)
+		}
+	}
+	return counts
+}
+
+func BenchmarkWorldBlocksCount(b *testing.B) {
+	world := openTestdataWorld(b)
+	defer world.Close()
+
+	for _, workers := range benchWorkerCounts() {
+		for _, batchSize := range []int{1, 16} {
+			b.Run(fmt.Sprintf("workers=%d/batch=%d", workers, batchSize), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := world.BlocksCount(mc.WithWorkers(workers), mc.WithBatchSize(batchSize)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkWorldFindBlocks(b *testing.B) {
+	world := openTestdataWorld(b)
+	defer world.Close()
+
+	for _, workers := range benchWorkerCounts() {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := world.FindBlocks([]string{"minecraft:spawner"}, mc.WithWorkers(workers)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
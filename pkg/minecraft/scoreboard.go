@@ -0,0 +1,101 @@
+package minecraft
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Objective struct {
+	Name        string
+	Criteria    string
+	DisplayName string
+	RenderType  string
+}
+
+// Score is the score of a single entry, usually a player's name, within
+// an objective.
+type Score struct {
+	Name      string
+	Objective string
+	Score     int
+	Locked    bool
+}
+
+type Team struct {
+	Name        string
+	DisplayName string
+	Color       string
+	Players     []string
+}
+
+type Scoreboard struct {
+	Objectives []Objective
+	Scores     []Score
+	Teams      []Team
+	// DisplaySlots maps each display slot, such as slot_1 for the sidebar,
+	// to the name of the objective shown within it.
+	DisplaySlots map[string]string
+}
+
+type scoreboardTag struct {
+	Data struct {
+		Objectives []struct {
+			Name         string
+			CriteriaName string
+			DisplayName  string
+			RenderType   string
+		}
+		PlayerScores []struct {
+			Name      string
+			Objective string
+			Score     int32
+			Locked    bool
+		}
+		Teams []struct {
+			Name        string
+			DisplayName string
+			TeamColor   string
+			Players     []string
+		}
+		DisplaySlots map[string]string
+	} `nbt:"data"`
+}
+
+// Scoreboard returns the world's scoreboard objectives, scores and teams.
+func (w World) Scoreboard() (Scoreboard, error) {
+	var st scoreboardTag
+	if err := w.decodeDataFile("scoreboard", Overworld, &st); err != nil {
+		return Scoreboard{}, fmt.Errorf("unable to read scoreboard: %w", err)
+	}
+
+	sb := Scoreboard{DisplaySlots: st.Data.DisplaySlots}
+	for _, o := range st.Data.Objectives {
+		sb.Objectives = append(sb.Objectives, Objective{
+			Name:        o.Name,
+			Criteria:    o.CriteriaName,
+			DisplayName: o.DisplayName,
+			RenderType:  o.RenderType,
+		})
+	}
+
+	for _, s := range st.Data.PlayerScores {
+		sb.Scores = append(sb.Scores, Score{Name: s.Name, Objective: s.Objective, Score: int(s.Score), Locked: s.Locked})
+	}
+
+	for _, t := range st.Data.Teams {
+		sb.Teams = append(sb.Teams, Team{Name: t.Name, DisplayName: t.DisplayName, Color: t.TeamColor, Players: t.Players})
+	}
+
+	sort.Slice(sb.Scores, func(i, j int) bool {
+		a, b := sb.Scores[i], sb.Scores[j]
+		if a.Objective != b.Objective {
+			return a.Objective < b.Objective
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Name < b.Name
+	})
+
+	return sb, nil
+}
@@ -0,0 +1,172 @@
+package minecraft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tauraamui/mcscan/internal/vfs"
+)
+
+// Stats are a player's statistics, keyed by category such as
+// minecraft:mined and then by stat such as minecraft:diamond_ore.
+type Stats struct {
+	UUID        string
+	DataVersion int
+	Values      map[string]map[string]int64
+}
+
+// StatKey identifies a single statistic, formatted as category/stat such
+// as minecraft:mined/minecraft:diamond_ore.
+type StatKey struct {
+	Category string
+	Stat     string
+}
+
+func (k StatKey) String() string {
+	return k.Category + "/" + k.Stat
+}
+
+// ParseStatKey parses a key as category/stat, adding the minecraft
+// namespace to either part if it's missing so mined/diamond_ore is
+// accepted.
+func ParseStatKey(key string) (StatKey, error) {
+	category, stat, ok := strings.Cut(key, "/")
+	if !ok || len(category) == 0 || len(stat) == 0 {
+		return StatKey{}, fmt.Errorf("stat key %s must be formatted as category/stat", key)
+	}
+
+	return StatKey{Category: namespaced(category), Stat: namespaced(stat)}, nil
+}
+
+func namespaced(id string) string {
+	if !strings.Contains(id, ":") {
+		return "minecraft:" + id
+	}
+	return id
+}
+
+// Get returns the value of the given stat, zero if the player has never
+// incremented it.
+func (s Stats) Get(key StatKey) int64 {
+	return s.Values[key.Category][key.Stat]
+}
+
+// Total returns the sum of every stat within the given category.
+func (s Stats) Total(category string) int64 {
+	var total int64
+	for _, v := range s.Values[category] {
+		total += v
+	}
+	return total
+}
+
+// Categories returns the categories the player has any stats within,
+// sorted by name.
+func (s Stats) Categories() []string {
+	categories := make([]string, 0, len(s.Values))
+	for c := range s.Values {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+type statsFile struct {
+	Stats       map[string]map[string]int64 `json:"stats"`
+	DataVersion int                         `json:"DataVersion"`
+}
+
+// Stats returns the statistics of every player who has joined the world,
+// sorted by UUID.
+func (w World) Stats() ([]Stats, error) {
+	found, err := vfs.Glob(w.fsys, filepath.Join(w.path, "stats", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]Stats, 0, len(found))
+	for _, f := range found {
+		s, err := w.readStats(f)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].UUID < stats[j].UUID
+	})
+
+	return stats, nil
+}
+
+// PlayerStats returns the statistics of the player with the given UUID,
+// the error wraps fs.ErrNotExist if the player has no stats.
+func (w World) PlayerStats(uuid string) (Stats, error) {
+	id, err := parseUUID(uuid)
+	if err != nil {
+		return Stats{}, err
+	}
+	return w.readStats(filepath.Join(w.path, "stats", id+".json"))
+}
+
+func (w World) readStats(path string) (Stats, error) {
+	uuid := strings.TrimSuffix(filepath.Base(path), ".json")
+
+	data, err := fs.ReadFile(w.fsys, path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Stats{}, fmt.Errorf("stats for player %s not found: %w", uuid, err)
+		}
+		return Stats{}, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+
+	var sf statsFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return Stats{}, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+
+	if sf.Stats == nil {
+		sf.Stats = map[string]map[string]int64{}
+	}
+
+	return Stats{UUID: uuid, DataVersion: sf.DataVersion, Values: sf.Stats}, nil
+}
+
+type LeaderboardEntry struct {
+	Rank  int
+	UUID  string
+	Value int64
+}
+
+// Leaderboard ranks every player with a non zero value for the given stat,
+// highest first. Players with equal values share the same rank.
+func Leaderboard(stats []Stats, key StatKey) []LeaderboardEntry {
+	entries := []LeaderboardEntry{}
+	for _, s := range stats {
+		if v := s.Get(key); v != 0 {
+			entries = append(entries, LeaderboardEntry{UUID: s.UUID, Value: v})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].UUID < entries[j].UUID
+	})
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+		if i > 0 && entries[i].Value == entries[i-1].Value {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+
+	return entries
+}
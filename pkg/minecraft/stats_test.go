@@ -0,0 +1,70 @@
+package minecraft_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
+)
+
+func TestWorldStatsLeaderboard(t *testing.T) {
+	fsys := fstest.MapFS{
+		"world/stats/00000000-0000-0000-0000-00000000000a.json": &fstest.MapFile{
+			Data: []byte(`{"stats":{"minecraft:mined":{"minecraft:diamond_ore":12,"minecraft:stone":300}},"DataVersion":3337}`),
+		},
+		"world/stats/00000000-0000-0000-0000-00000000000b.json": &fstest.MapFile{
+			Data: []byte(`{"stats":{"minecraft:mined":{"minecraft:diamond_ore":40}},"DataVersion":3337}`),
+		},
+		"world/stats/00000000-0000-0000-0000-00000000000c.json": &fstest.MapFile{
+			Data: []byte(`{"stats":{"minecraft:mined":{"minecraft:diamond_ore":12}},"DataVersion":3337}`),
+		},
+		"world/stats/00000000-0000-0000-0000-00000000000d.json": &fstest.MapFile{
+			Data: []byte(`{"stats":{"minecraft:custom":{"minecraft:jump":5}},"DataVersion":3337}`),
+		},
+	}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	stats, err := world.Stats()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stats) != 4 {
+		t.Fatalf("expected stats for 4 players, got %d", len(stats))
+	}
+
+	if total := stats[0].Total("minecraft:mined"); total != 312 {
+		t.Errorf("expected 312 blocks mined, got %d", total)
+	}
+
+	key, err := mc.ParseStatKey("mined/diamond_ore")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if key.String() != "minecraft:mined/minecraft:diamond_ore" {
+		t.Errorf("unexpected key %s", key)
+	}
+
+	got := mc.Leaderboard(stats, key)
+	want := []mc.LeaderboardEntry{
+		{Rank: 1, UUID: "00000000-0000-0000-0000-00000000000b", Value: 40},
+		{Rank: 2, UUID: "00000000-0000-0000-0000-00000000000a", Value: 12},
+		{Rank: 2, UUID: "00000000-0000-0000-0000-00000000000c", Value: 12},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
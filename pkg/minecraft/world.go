@@ -3,42 +3,58 @@ package minecraft
 import (
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	stdos "os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
+	"github.com/Tnze/go-mc/level/block"
 	"github.com/Tnze/go-mc/nbt"
 	"github.com/Tnze/go-mc/save"
-	mcregion "github.com/Tnze/go-mc/save/region"
 	"github.com/hack-pad/hackpadfs"
 	"github.com/tauraamui/mcscan/internal/filesystem"
-	"github.com/tauraamui/mcscan/internal/vfs"
+	"github.com/tauraamui/mcscan/internal/nbtree"
 )
 
 type World struct {
-	fsys    filesystem.FS
-	dirFD   fs.File
-	path    string
-	name    string
-	lvlFD   fs.File
-	regions []region
+	fsys       filesystem.FS
+	dirFD      fs.File
+	path       string
+	name       string
+	lvlFD      fs.File
+	dimensions []Dimension
+	regionFDs  *openRegions
+	cfg        worldConfig
+	// lock is held on session.lock until the world is closed, if opened
+	// WithSessionLock and not in use elsewhere.
+	lock  io.Closer
+	inUse bool
 }
 
+// ErrWorldInUse is returned by writes to a world whose session.lock is held
+// by another process, such as the game or a server.
+var ErrWorldInUse = errors.New("world is in use, its session.lock is held by another process")
+
 type region struct {
 	fsys filesystem.FS
 	fd   fs.File
 	path string
+	pos  RegionPos
 }
 
 func (r *region) Read(p []byte) (n int, err error) {
 	return r.fd.Read(p)
 }
 
+// Write always fails, as each write would replace the whole file. Chunks
+// are written through World.WriteNBT instead, which backs up the file.
 func (r *region) Write(p []byte) (n int, err error) {
-	return len(p), r.fsys.WriteFile(r.path, p, fs.ModePerm)
+	return 0, fmt.Errorf("unable to write %s: region files are only written through WriteNBT", r.path)
 }
 
 func (r *region) Seek(offset int64, whence int) (int64, error) {
@@ -49,16 +65,123 @@ func (r *region) Close() error {
 	return r.fd.Close()
 }
 
+// openRegions tracks every region file currently open, so that any left
+// open by an in progress scan are closed along with the world.
+type openRegions struct {
+	mu  sync.Mutex
+	fds map[fs.File]struct{}
+}
+
+func (o *openRegions) open(fsys filesystem.FS, path string) (fs.File, error) {
+	fd, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.fds == nil {
+		o.fds = map[fs.File]struct{}{}
+	}
+	o.fds[fd] = struct{}{}
+
+	return fd, nil
+}
+
+// close closes the given region file, unless it has already been closed
+// by closeAll.
+func (o *openRegions) close(fd fs.File) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.fds[fd]; !ok {
+		return nil
+	}
+	delete(o.fds, fd)
+
+	return fd.Close()
+}
+
+func (o *openRegions) closeAll() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var firstErr error
+	for fd := range o.fds {
+		if err := fd.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(o.fds, fd)
+	}
+
+	return firstErr
+}
+
 type Level struct {
 	save.LevelData
 }
 
-type WorldResolver func(fsys filesystem.FS, ref string) (*World, error)
+type WorldResolver func(fsys filesystem.FS, ref string, opts ...WorldOption) (*World, error)
+
+// WorldOption configures how a world's files are written.
+type WorldOption func(cfg *worldConfig)
 
-func OpenWorldByName(fsys filesystem.FS, name string) (*World, error) {
-	configDirPath := must(stdos.UserConfigDir())
-	configDirPath = strings.TrimPrefix(configDirPath, string(filepath.Separator))
-	worldSaveDirPath := filepath.Join(configDirPath, "minecraft", "saves", name)
+type worldConfig struct {
+	dryRun      func(FileDiff)
+	noBackups   bool
+	ignoreLock  bool
+	sessionLock bool
+}
+
+// WithDryRun makes writes report the changes they would make to report
+// rather than make them.
+func WithDryRun(report func(FileDiff)) WorldOption {
+	return func(cfg *worldConfig) {
+		cfg.dryRun = report
+	}
+}
+
+// WithoutBackups stops files from being backed up before they're written.
+func WithoutBackups() WorldOption {
+	return func(cfg *worldConfig) {
+		cfg.noBackups = true
+	}
+}
+
+// WithIgnoreLock allows writes to a world which is in use by another
+// process, risking the game overwriting them or corrupting the world.
+func WithIgnoreLock() WorldOption {
+	return func(cfg *worldConfig) {
+		cfg.ignoreLock = true
+	}
+}
+
+// WithSessionLock takes the world's session.lock on open, the same way the
+// game does, holding it until the world is closed so that the game can't
+// open the world while it's being edited. Should another process hold it,
+// the world is opened read only and InUse reports so. Without it, the lock
+// is only taken while files are written.
+func WithSessionLock() WorldOption {
+	return func(cfg *worldConfig) {
+		cfg.sessionLock = true
+	}
+}
+
+// SavePath returns the OS path of the world of the given name within the
+// game's saves directory.
+func SavePath(name string) (string, error) {
+	configDirPath, err := stdos.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDirPath, "minecraft", "saves", name), nil
+}
+
+func OpenWorldByName(fsys filesystem.FS, name string, opts ...WorldOption) (*World, error) {
+	savePath, err := SavePath(name)
+	if err != nil {
+		return nil, err
+	}
+	worldSaveDirPath := strings.TrimPrefix(savePath, string(filepath.Separator))
 
 	fi, err := fsys.Stat(worldSaveDirPath)
 	if err != nil {
@@ -69,43 +192,74 @@ func OpenWorldByName(fsys filesystem.FS, name string) (*World, error) {
 		return nil, fmt.Errorf("found %s but is not directory", worldSaveDirPath)
 	}
 
-	return OpenWorld(fsys, worldSaveDirPath)
+	return OpenWorld(fsys, worldSaveDirPath, opts...)
 }
 
-func OpenWorld(fsys filesystem.FS, path string) (*World, error) {
+func OpenWorld(fsys filesystem.FS, path string, opts ...WorldOption) (*World, error) {
 	fd, err := fsys.Open(path)
 	if err != nil {
 		return nil, err
 	}
 
-	w := World{fsys: fsys, dirFD: fd, path: path, name: filepath.Base(path)}
+	w := World{fsys: fsys, dirFD: fd, path: path, name: filepath.Base(path), regionFDs: &openRegions{}}
+	for _, opt := range opts {
+		opt(&w.cfg)
+	}
 
-	if err := w.resolveRegions(); err != nil {
+	if err := w.resolveDimensions(); err != nil {
+		fd.Close()
+		return nil, err
+	}
+
+	if !w.cfg.sessionLock {
+		return &w, nil
+	}
+
+	// should the filesystem be unable to lock files, the world is left
+	// unlocked
+	lock, err := w.takeLock()
+	switch {
+	case err == nil:
+		w.lock = lock
+	case errors.Is(err, ErrWorldInUse):
+		w.inUse = true
+	default:
+		fd.Close()
 		return nil, err
 	}
 
 	return &w, nil
 }
 
-func (w *World) resolveRegions() error {
-	regionFiles := filepath.Join(w.path, "region", "*.mca")
+// InUse reports whether another process, such as the game or a server,
+// held the world's session.lock when it was opened WithSessionLock. Writes
+// fail with ErrWorldInUse if so, unless the world was opened WithIgnoreLock.
+func (w World) InUse() bool {
+	return w.inUse
+}
 
-	found, err := vfs.Glob(w.fsys, regionFiles)
+// takeLock locks the world's session.lock, failing with ErrWorldInUse if
+// another process holds it.
+func (w World) takeLock() (io.Closer, error) {
+	lock, err := filesystem.Lock(w.fsys, filepath.Join(w.path, "session.lock"))
 	if err != nil {
-		return err
-	}
-
-	for _, f := range found {
-		w.regions = append(w.regions, region{fsys: w.fsys, path: f})
+		if errors.Is(err, filesystem.ErrLocked) {
+			return nil, ErrWorldInUse
+		}
+		return nil, fmt.Errorf("unable to lock session.lock: %w", err)
 	}
-
-	return nil
+	return lock, nil
 }
 
 func (w World) Name() string {
 	return w.name
 }
 
+// Path returns the world's path within the filesystem it was opened from.
+func (w World) Path() string {
+	return w.path
+}
+
 func (w World) ReadLevel() (*Level, error) {
 	fd, err := w.fsys.Open(filepath.Join(w.path, "level.dat"))
 	if err != nil {
@@ -125,6 +279,8 @@ func (w World) ReadLevel() (*Level, error) {
 	return &Level{lvl.Data}, nil
 }
 
+// WriteLevel replaces level.dat with the given level data. Any tag not
+// held by Level is lost, so EditLevel should be preferred.
 func (w World) WriteLevel(lvl *Level) error {
 	lll := save.Level{Data: save.LevelData(lvl.LevelData)}
 	data, err := nbt.Marshal(lll)
@@ -132,65 +288,181 @@ func (w World) WriteLevel(lvl *Level) error {
 		return err
 	}
 
-	var buf bytes.Buffer
-	gw := gzip.NewWriter(&buf)
-	if _, err := gw.Write(data); err != nil {
+	root, err := nbtree.Read(bytes.NewReader(data))
+	if err != nil {
 		return err
 	}
-	gw.Close()
 
-	return w.fsys.WriteFile(filepath.Join(w.path, "level.dat"), buf.Bytes(), fs.ModePerm)
+	return w.WriteNBT(&NBTFile{Path: "level.dat", Root: root, compression: compressionGzip})
 }
 
 func (w World) RegionsCount() int {
-	return len(w.regions)
+	count := 0
+	for _, dim := range w.dimensions {
+		count += dim.RegionsCount()
+	}
+	return count
 }
 
-func (w World) BlocksCount() (map[string]uint64, error) {
-	count := map[string]uint64{}
-	if len(w.regions) == 0 {
-		return count, nil
+// BlocksCount returns the total of each block ID found, keyed by the ID of
+// the dimension the blocks were found in. Blocks are counted once each by
+// their state, so block entities, such as a chest's contents, add nothing.
+func (w World) BlocksCount(opts ...ScanOption) (map[string]map[string]uint64, error) {
+	cfg := resolveScanConfig(opts)
+	dims, err := w.selectDimensions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.countCache != nil && cfg.area != nil {
+		return nil, errors.New("a blocks count cache can't be used within an area")
 	}
 
-	blocks := make(chan Block)
-	wg := sync.WaitGroup{}
-	for i := 0; i < len(w.regions); i++ {
-		rref := w.regions[i]
-		fd, err := w.fsys.Open(rref.path)
+	counts := map[string]map[string]uint64{}
+	for _, dim := range dims {
+		var count map[string]uint64
+		if cfg.countCache != nil {
+			count, err = w.cachedRegionsBlocksCount(cfg, dim, nil)
+		} else {
+			count, err = w.regionsBlocksCount(cfg, dim.regions)
+		}
 		if err != nil {
 			return nil, err
 		}
-		rref.fd = fd
+		counts[dim.ID] = count
+	}
+
+	return counts, nil
+}
+
+// ChunkBlocksCount returns the total of each block ID found within each
+// chunk, keyed by the ID of the dimension the chunks are within. With a
+// blocks count cache, chunks unchanged since they were cached aren't
+// decoded, as with BlocksCount.
+func (w World) ChunkBlocksCount(opts ...ScanOption) (map[string]map[ChunkPos]map[string]uint64, error) {
+	cfg := resolveScanConfig(opts)
+	dims, err := w.selectDimensions(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-		loadedRegion, err := mcregion.Load(&rref)
+	if cfg.countCache != nil && cfg.area != nil {
+		return nil, errors.New("a blocks count cache can't be used within an area")
+	}
+
+	counts := map[string]map[ChunkPos]map[string]uint64{}
+	for _, dim := range dims {
+		var count map[ChunkPos]map[string]uint64
+		if cfg.countCache != nil {
+			count = map[ChunkPos]map[string]uint64{}
+			_, err = w.cachedRegionsBlocksCount(cfg, dim, count)
+		} else {
+			count, err = w.chunksBlocksCount(cfg, dim.regions)
+		}
 		if err != nil {
 			return nil, err
 		}
+		counts[dim.ID] = count
+	}
 
-		wg.Add(1)
-		go func(wg *sync.WaitGroup) {
-			defer wg.Done()
-			ReadRegionsBlocks(loadedRegion, blocks)
-		}(&wg)
+	return counts, nil
+}
+
+func (w World) regionsBlocksCount(cfg scanConfig, regions []region) (map[string]uint64, error) {
+	// each worker counts by state ID into its own slice, which are then
+	// merged and keyed by block ID once every chunk has been decoded
+	workerCounts := [][]uint64{}
+	err := w.scanChunks(cfg, regions, func() chunkWorker {
+		counts := make([]uint64, len(block.StateList))
+		workerCounts = append(workerCounts, counts)
+		return terrainWorker(func(c *decodedChunk) error {
+			return c.countStates(counts, cfg.area)
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	go func(wg *sync.WaitGroup, bc chan Block) {
-		defer close(bc)
-		wg.Wait()
-	}(&wg, blocks)
+	count := map[string]uint64{}
+	for state := range block.StateList {
+		var total uint64
+		for _, counts := range workerCounts {
+			total += counts[state]
+		}
 
-	for blk := range blocks {
-		existingCount, ok := count[blk.ID]
-		if ok {
-			count[blk.ID] = existingCount + 1
+		if total == 0 || block.IsAir(block.StateID(state)) {
 			continue
 		}
-		count[blk.ID] = 1
+
+		count[block.StateList[state].ID()] += total
 	}
 
 	return count, nil
 }
 
+// FindBlocks returns every block matching any of the given block IDs, keyed
+// by the ID of the dimension the blocks were found in.
+func (w World) FindBlocks(ids []string, opts ...ScanOption) (map[string][]Block, error) {
+	cfg := resolveScanConfig(opts)
+	dims, err := w.selectDimensions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	wantedIDs := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		wantedIDs[id] = struct{}{}
+	}
+
+	wanted := make([]bool, len(block.StateList))
+	for state, b := range block.StateList {
+		if _, ok := wantedIDs[b.ID()]; ok {
+			wanted[state] = true
+		}
+	}
+
+	found := map[string][]Block{}
+	for _, dim := range dims {
+		workerBlocks := []*[]Block{}
+		err := w.scanChunks(cfg, dim.regions, func() chunkWorker {
+			blocks := []Block{}
+			workerBlocks = append(workerBlocks, &blocks)
+			return terrainWorker(func(c *decodedChunk) (err error) {
+				blocks, err = c.appendBlocks(blocks, wanted, cfg.area)
+				return err
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		blocks := []Block{}
+		for _, wb := range workerBlocks {
+			blocks = append(blocks, *wb...)
+		}
+
+		sort.Slice(blocks, func(i, j int) bool {
+			return lessBlock(blocks[i], blocks[j])
+		})
+		found[dim.ID] = blocks
+	}
+
+	return found, nil
+}
+
+func lessBlock(a, b Block) bool {
+	if a.ID != b.ID {
+		return a.ID < b.ID
+	}
+	if a.Pos.X != b.Pos.X {
+		return a.Pos.X < b.Pos.X
+	}
+	if a.Pos.Z != b.Pos.Z {
+		return a.Pos.Z < b.Pos.Z
+	}
+	return a.Pos.Y < b.Pos.Y
+}
+
 func (w World) Close() error {
 	// TODO(tauraamui): Should handle errors as independant close failures,
 	// and append each error occurance to an errgroup to return at end.
@@ -206,5 +478,17 @@ func (w World) Close() error {
 		}
 	}
 
+	if w.regionFDs != nil {
+		if err := w.regionFDs.closeAll(); err != nil {
+			return err
+		}
+	}
+
+	if w.lock != nil {
+		if err := w.lock.Close(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -1,10 +1,21 @@
 package minecraft_test
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"sync"
 	"testing"
 	"testing/fstest"
+
+	"github.com/tauraamui/mcscan/internal/filesystem/filesystemtest"
+	mc "github.com/tauraamui/mcscan/pkg/minecraft"
 )
 
+type mockFS = filesystemtest.MapFS
+
 func buildMockFS() fstest.MapFS {
 	return fstest.MapFS{
 		"config/minecraft/saves/test world/region/r.0.0.mca": &fstest.MapFile{
@@ -23,3 +34,280 @@ func TestWorldOpenReturnsWorldReferenceWithNoError(t *testing.T) {
 		is.Equal(world.Name(), "test world")
 	*/
 }
+
+func TestWorldOpenResolvesAllDimensions(t *testing.T) {
+	fsys := buildMockFS()
+	fsys["config/minecraft/saves/test world/DIM-1/region/r.0.0.mca"] = &fstest.MapFile{Data: region0}
+	fsys["config/minecraft/saves/test world/DIM1/data/raids_end.dat"] = &fstest.MapFile{}
+	fsys["config/minecraft/saves/test world/dimensions/mcscan/mining/region/r.0.0.mca"] = &fstest.MapFile{Data: region0}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "config/minecraft/saves/test world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	expected := map[string]int{
+		mc.Overworld:    1,
+		mc.Nether:       1,
+		mc.End:          0,
+		"mcscan:mining": 1,
+	}
+
+	dims := world.Dimensions()
+	if len(dims) != len(expected) {
+		t.Fatalf("expected %d dimensions, got %d", len(expected), len(dims))
+	}
+
+	for _, dim := range dims {
+		regionsCount, ok := expected[dim.ID]
+		if !ok {
+			t.Fatalf("unexpected dimension %s", dim.ID)
+		}
+
+		if dim.RegionsCount() != regionsCount {
+			t.Errorf("expected %d regions in %s, got %d", regionsCount, dim.ID, dim.RegionsCount())
+		}
+	}
+
+	if world.RegionsCount() != 3 {
+		t.Errorf("expected 3 regions in total, got %d", world.RegionsCount())
+	}
+}
+
+func TestWorldOpenSkipsStrayRegionFiles(t *testing.T) {
+	fsys := buildMockFS()
+	fsys["config/minecraft/saves/test world/region/r.0.0 (1).mca"] = &fstest.MapFile{Data: region0}
+	fsys["config/minecraft/saves/test world/region/r.0.0.mca.bak.mca"] = &fstest.MapFile{Data: region0}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "config/minecraft/saves/test world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	if world.RegionsCount() != 1 {
+		t.Errorf("expected 1 region, got %d", world.RegionsCount())
+	}
+}
+
+func TestParseDimensionID(t *testing.T) {
+	tests := map[string]string{
+		"nether":            mc.Nether,
+		"end":               mc.End,
+		"overworld":         mc.Overworld,
+		"the_nether":        mc.Nether,
+		"minecraft:the_end": mc.End,
+		"mcscan:mining":     "mcscan:mining",
+		"custom":            "minecraft:custom",
+	}
+
+	for ref, expected := range tests {
+		if id := mc.ParseDimensionID(ref); id != expected {
+			t.Errorf("ParseDimensionID(%q) = %q, expected %q", ref, id, expected)
+		}
+	}
+}
+
+func corruptRegion0() []byte {
+	data := make([]byte, len(region0))
+	copy(data, region0)
+
+	// the first chunk's data begins at the third sector, after its 4 byte
+	// length, so replace its compression type with one which is unknown
+	data[2*4096+4] = 0xFF
+
+	return data
+}
+
+func TestWorldBlocksCountCountsBlocksWithEntitiesOnce(t *testing.T) {
+	type blockEntity struct {
+		ID         string `nbt:"id"`
+		X          int32  `nbt:"x"`
+		Y          int32  `nbt:"y"`
+		Z          int32  `nbt:"z"`
+		KeepPacked bool   `nbt:"keepPacked"`
+	}
+
+	beacons := solidChunk(0, 0, "minecraft:beacon")
+	beacons.BlockEntities = []blockEntity{{ID: "minecraft:beacon"}, {ID: "minecraft:beacon", X: 1}}
+	spawners := solidChunk(1, 0, "minecraft:spawner")
+	spawners.BlockEntities = []blockEntity{{ID: "minecraft:mob_spawner", X: 16}}
+
+	fsys := fstest.MapFS{"world/region/r.0.0.mca": &fstest.MapFile{Data: buildRegion(t, beacons, spawners)}}
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	counts, err := world.BlocksCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// neither the beacons' nor the spawner's block entities are counted
+	expected := map[string]uint64{"minecraft:beacon": 4096, "minecraft:spawner": 4096}
+	if !reflect.DeepEqual(counts[mc.Overworld], expected) {
+		t.Errorf("expected %v, got %v", expected, counts[mc.Overworld])
+	}
+}
+
+func TestWorldBlocksCountAbortsOnCorruptChunk(t *testing.T) {
+	fsys := buildMockFS()
+	fsys["config/minecraft/saves/test world/region/r.0.0.mca"] = &fstest.MapFile{Data: corruptRegion0()}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "config/minecraft/saves/test world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	_, err = world.BlocksCount()
+
+	var corruptErr *mc.ChunkCorruptError
+	if !errors.As(err, &corruptErr) {
+		t.Fatalf("expected chunk corrupt error, got %v", err)
+	}
+
+	if corruptErr.Chunk != (mc.ChunkPos{X: 0, Z: 0}) {
+		t.Errorf("expected corrupt chunk 0,0, got %d,%d", corruptErr.Chunk.X, corruptErr.Chunk.Z)
+	}
+}
+
+func TestWorldBlocksCountSkipsAndReportsCorruptChunk(t *testing.T) {
+	fsys := buildMockFS()
+	fsys["config/minecraft/saves/test world/region/r.0.0.mca"] = &fstest.MapFile{Data: corruptRegion0()}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "config/minecraft/saves/test world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	var corrupted []*mc.ChunkCorruptError
+	counts, err := world.BlocksCount(mc.SkipCorruptChunks(func(err *mc.ChunkCorruptError) {
+		corrupted = append(corrupted, err)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(corrupted) != 1 {
+		t.Fatalf("expected 1 corrupt chunk to be reported, got %d", len(corrupted))
+	}
+
+	if _, ok := counts[mc.Overworld]; !ok {
+		t.Error("expected overworld counts despite corrupt chunk")
+	}
+}
+
+func TestWorldBlocksCountSkipsAndReportsRegionWithCorruptHeader(t *testing.T) {
+	fsys := buildMockFS()
+	fsys["config/minecraft/saves/test world/region/r.1.0.mca"] = &fstest.MapFile{Data: region0[:1000]}
+
+	world, err := mc.OpenWorld(mockFS{MapFS: fsys}, "config/minecraft/saves/test world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer world.Close()
+
+	var corruptErr *mc.ChunkCorruptError
+	if _, err := world.BlocksCount(); !errors.As(err, &corruptErr) || !corruptErr.WholeRegion {
+		t.Fatalf("expected whole region corrupt error, got %v", err)
+	}
+
+	// cached counts are left stale so that the region is reported each time
+	cache := memCountCache{regions: map[regionKey]mc.RegionCounts{}, chunks: map[regionKey]map[mc.ChunkPos]mc.ChunkCounts{}}
+	for i := 0; i < 2; i++ {
+		var corrupted []*mc.ChunkCorruptError
+		counts, err := world.BlocksCount(mc.WithBlocksCountCache(cache), mc.SkipCorruptChunks(func(err *mc.ChunkCorruptError) {
+			corrupted = append(corrupted, err)
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(corrupted) != 1 || !corrupted[0].WholeRegion || corrupted[0].Region != "config/minecraft/saves/test world/region/r.1.0.mca" {
+			t.Fatalf("expected r.1.0.mca to be reported as corrupt, got %v", corrupted)
+		}
+
+		if _, ok := counts[mc.Overworld]; !ok {
+			t.Error("expected overworld counts despite corrupt region")
+		}
+	}
+}
+
+// countingFS tracks how many files are open at once.
+type countingFS struct {
+	mockFS
+	mu   sync.Mutex
+	open int
+	peak int
+}
+
+type countedFile struct {
+	fs.File
+	fsys *countingFS
+}
+
+func (c *countedFile) Seek(offset int64, whence int) (int64, error) {
+	return c.File.(io.Seeker).Seek(offset, whence)
+}
+
+func (c *countedFile) Close() error {
+	c.fsys.mu.Lock()
+	c.fsys.open--
+	c.fsys.mu.Unlock()
+	return c.File.Close()
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	f, err := c.mockFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.open++
+	if c.open > c.peak {
+		c.peak = c.open
+	}
+
+	return &countedFile{File: f, fsys: c}, nil
+}
+
+func TestWorldBlocksCountOpensRegionsWithinBound(t *testing.T) {
+	fsys := buildMockFS()
+	for i := 1; i < 6; i++ {
+		fsys[fmt.Sprintf("config/minecraft/saves/test world/region/r.%d.0.mca", i)] = &fstest.MapFile{Data: region0}
+	}
+	cfs := &countingFS{mockFS: mockFS{MapFS: fsys}}
+
+	world, err := mc.OpenWorld(cfs, "config/minecraft/saves/test world")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the world's own directory remains open throughout
+	if _, err := world.BlocksCount(mc.WithMaxOpenRegions(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfs.peak > 3 {
+		t.Errorf("expected at most 2 regions open at once, peaked at %d", cfs.peak-1)
+	}
+
+	if cfs.open != 1 {
+		t.Errorf("expected every region to be closed after scan, %d still open", cfs.open-1)
+	}
+
+	if err := world.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfs.open != 0 {
+		t.Errorf("expected no files open after world close, %d still open", cfs.open)
+	}
+}